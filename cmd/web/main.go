@@ -1,46 +1,92 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
-	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/handlers"
 	httpx "github.com/BohoBytes/dhakahome-web/internal/http"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment file (.env.local, .env.staging, etc.)
-	// Priority: ENV_FILE env var > .env.local > .env
-	envFile := os.Getenv("ENV_FILE")
-	if envFile == "" {
-		// Try .env.local first (for VS Code launch configs)
-		if _, err := os.Stat(".env.local"); err == nil {
-			envFile = ".env.local"
-		} else {
-			envFile = ".env"
+	cfg, err := config.Load()
+	log.Print(cfg.Summary())
+	if err != nil {
+		if cfg.IsProduction() {
+			log.Fatalf("refusing to start: %v", err)
 		}
+		log.Printf("warning: %v", err)
 	}
 
-	if err := godotenv.Load(envFile); err != nil {
-		log.Printf("warning: could not load %s: %v", envFile, err)
-	} else {
-		env := get("ENVIRONMENT", "local")
-		log.Printf("✅ Loaded environment: %s (from %s)", env, envFile)
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           httpx.NewRouter(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		// longer than the slowest route timeout (the shortlist exports)
+		WriteTimeout: 90 * time.Second,
+		IdleTimeout:  120 * time.Second,
 	}
 
-	addr := get("ADDR", ":5173")
-	r := httpx.NewRouter()
+	workers, stopWorkers := context.WithCancel(context.Background())
+	running := []<-chan struct{}{
+		handlers.StartLeadOutbox(workers),
+		handlers.StartSavedSearchAlerts(workers),
+		handlers.StartPropertyWatcher(workers),
+	}
+
+	stop, unnotify := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer unnotify()
 
-	log.Printf("🚀 dhakahome-web listening on %s", addr)
-	if err := http.ListenAndServe(addr, r); err != nil {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
 		log.Fatal(err)
 	}
-}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+	log.Printf("🚀 dhakahome-web listening on %s", srv.Addr)
+	handlers.SetReady(true)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+		return
+	case <-stop.Done():
+	}
+	unnotify() // a second signal kills the process straight away
 
-func get(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
+	// Report not-ready first and give the load balancer a moment to notice before
+	// the listener closes.
+	handlers.SetReady(false)
+	delay := cfg.HTTP.ShutdownReadyDelay
+	log.Printf("🛑 Shutting down: not ready, draining in %s", delay)
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("shutdown: in-flight requests cut off: %v", err)
+	}
+
+	stopWorkers()
+	for _, done := range running {
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	}
+	handlers.DrainLeadOutbox(ctx)
+	if ctx.Err() != nil {
+		log.Printf("shutdown: timed out; undelivered leads stay in the outbox for the next start")
 	}
-	return def
+	log.Printf("👋 dhakahome-web stopped")
 }
@@ -3,8 +3,11 @@ module github.com/BohoBytes/dhakahome-web
 go 1.22
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/go-chi/chi/v5 v5.0.11
+	github.com/go-chi/cors v1.2.1
 	github.com/go-chi/httplog v0.2.5
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/joho/godotenv v1.5.1
 )
 
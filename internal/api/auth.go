@@ -3,11 +3,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
 )
 
 type AuthUser struct {
@@ -70,6 +73,45 @@ func (c *Client) LoginUser(email, password string) (LoginResponse, error) {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
+	return c.doLogin(req)
+}
+
+// ErrPhoneLoginUnavailable means there is no way to turn a verified phone number into a
+// Nestlo session: the API documents email/password login only.
+var ErrPhoneLoginUnavailable = errors.New("phone login: Nestlo has no documented phone login")
+
+// PhoneLoginAvailable reports whether LoginWithPhone can start a session. Until Nestlo
+// documents a phone login, only mock auth (MOCK_ENABLED with MOCK_AUTH_ENABLED) can.
+func PhoneLoginAvailable() bool {
+	cfg := config.Get()
+	return cfg.Mock.Enabled && cfg.Mock.AuthEnabled
+}
+
+// LoginWithPhone exchanges an OTP-verified Bangladeshi mobile number for a session. Only
+// mock auth supports it; otherwise it returns ErrPhoneLoginUnavailable.
+func (c *Client) LoginWithPhone(phone string) (LoginResponse, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return LoginResponse{}, fmt.Errorf("phone login: phone is required")
+	}
+	if !c.mockEnabled || !c.mockAuthEnabled {
+		return LoginResponse{}, ErrPhoneLoginUnavailable
+	}
+
+	now := time.Now().Unix()
+	return LoginResponse{
+		Token: fmt.Sprintf("mock-token-%d", now),
+		User: AuthUser{
+			ID:          fmt.Sprintf("mock-user-%s", strings.TrimPrefix(phone, "+")),
+			Name:        phone,
+			Role:        "tenant",
+			Status:      "active",
+			PhoneNumber: phone,
+		},
+	}, nil
+}
+
+func (c *Client) doLogin(req *http.Request) (LoginResponse, error) {
 	start := time.Now()
 	res, err := c.HC.Do(req)
 	if err != nil {
@@ -3,19 +3,22 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
 )
 
 const defaultStatusFilter = "listed_rental,listed_sale"
@@ -55,24 +58,12 @@ type Client struct {
 }
 
 func New() *Client {
-	// Check if mock mode is enabled
-	mockEnabled := strings.ToLower(strings.TrimSpace(os.Getenv("MOCK_ENABLED")))
-	useMock := mockEnabled == "true" || mockEnabled == "1" || mockEnabled == "yes"
-	mockAuth := useMock
-	if v := strings.ToLower(strings.TrimSpace(os.Getenv("MOCK_AUTH_ENABLED"))); v != "" {
-		mockAuth = v == "true" || v == "1" || v == "yes"
-	}
-
-	base := getenv("API_BASE_URL", "http://localhost:3000/api/v1")
-	scope := strings.TrimSpace(getenv("API_TOKEN_SCOPE", "assets.read"))
-	if scope == "" {
-		scope = "assets.read"
-	}
-
-	staticToken := strings.TrimSpace(getenv("API_AUTH_TOKEN", ""))
-	clientID := strings.TrimSpace(os.Getenv("API_CLIENT_ID"))
-	clientSecret := strings.TrimSpace(os.Getenv("API_CLIENT_SECRET"))
-	tokenURL := strings.TrimSpace(getenv("API_AUTH_URL", deriveTokenURL(base)))
+	cfg := config.Get()
+	useMock, mockAuth := cfg.Mock.Enabled, cfg.Mock.AuthEnabled
+	base := cfg.API.BaseURL
+	staticToken := cfg.API.AuthToken
+	clientID, clientSecret := cfg.API.ClientID, cfg.API.ClientSecret
+	tokenURL := cfg.API.AuthURL
 
 	if useMock {
 		log.Printf("🎭 API Client: MOCK MODE ENABLED - property searches use mock data; leads will still call Nestlo APIs")
@@ -91,30 +82,12 @@ func New() *Client {
 		tokenURL:        tokenURL,
 		clientID:        clientID,
 		clientSecret:    clientSecret,
-		scope:           scope,
+		scope:           cfg.API.TokenScope,
 		mockEnabled:     useMock,
 		mockAuthEnabled: mockAuth,
 	}
 }
 
-func getenv(k, d string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
-	}
-	return d
-}
-
-func deriveTokenURL(base string) string {
-	u, err := url.Parse(base)
-	if err != nil {
-		return "http://localhost:3000/oauth/token"
-	}
-	u.Path = "/oauth/token"
-	u.RawQuery = ""
-	u.Fragment = ""
-	return u.String()
-}
-
 type Property struct {
 	ID            string   `json:"id"`
 	Title         string   `json:"title"`
@@ -138,10 +111,28 @@ type Property struct {
 	HasImages     bool     `json:"-"`
 	IsShortlisted bool     `json:"is_shortlisted,omitempty"`
 	ShortlistID   string   `json:"shortlist_id,omitempty"`
-	ContactPhone  string   `json:"contactPhone,omitempty"`
-	ContactEmail  string   `json:"contactEmail,omitempty"`
-	Latitude      float64  `json:"latitude,omitempty"`
-	Longitude     float64  `json:"longitude,omitempty"`
+
+	// Per-user shortlist annotations, only set on shortlist views.
+	ShortlistNotes  string    `json:"shortlist_notes,omitempty"`
+	ShortlistTags   []string  `json:"shortlist_tags,omitempty"`
+	ShortlistRating int       `json:"shortlist_rating,omitempty"`
+	ShortlistedAt   time.Time `json:"-"`
+
+	ContactPhone string  `json:"contactPhone,omitempty"`
+	ContactEmail string  `json:"contactEmail,omitempty"`
+	Neighborhood string  `json:"neighborhood,omitempty"`
+	City         string  `json:"city,omitempty"`
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+}
+
+// IsAvailable reports whether the listing is still on the market (not leased, sold or withdrawn).
+func (p Property) IsAvailable() bool {
+	switch strings.ToLower(strings.TrimSpace(p.ListingType)) {
+	case "leased", "rented", "sold", "archived", "removed", "unlisted", "inactive", "draft":
+		return false
+	}
+	return p.ID != ""
 }
 
 type Document struct {
@@ -309,6 +300,7 @@ func (c *Client) CheckShortlist(assetID, userToken string) (ShortlistStatus, err
 
 // AddToShortlist adds a property to the default shortlist for the authenticated user.
 func (c *Client) AddToShortlist(assetID, userToken string) (ShortlistStatus, error) {
+	defer forgetMembership(userToken)
 	assetID = strings.TrimSpace(assetID)
 	if assetID == "" {
 		return ShortlistStatus{}, fmt.Errorf("asset id is required")
@@ -360,6 +352,7 @@ func (c *Client) AddToShortlist(assetID, userToken string) (ShortlistStatus, err
 
 // RemoveFromShortlist removes a property from all shortlists for the authenticated user.
 func (c *Client) RemoveFromShortlist(assetID, userToken string) (ShortlistStatus, error) {
+	defer forgetMembership(userToken)
 	assetID = strings.TrimSpace(assetID)
 	if assetID == "" {
 		return ShortlistStatus{}, fmt.Errorf("asset id is required")
@@ -410,7 +403,7 @@ func (c *Client) RemoveFromShortlist(assetID, userToken string) (ShortlistStatus
 	return status, nil
 }
 
-// ListShortlisted fetches the current user's shortlisted properties with pagination support.
+// ListShortlisted fetches the current user's default shortlist with pagination support.
 func (c *Client) ListShortlisted(userToken string, page, limit int) (PropertyList, error) {
 	if page <= 0 {
 		page = 1
@@ -436,72 +429,7 @@ func (c *Client) ListShortlisted(userToken string, page, limit int) (PropertyLis
 		return PropertyList{}, err
 	}
 
-	params := url.Values{}
-	params.Set("page", strconv.Itoa(page))
-	params.Set("limit", strconv.Itoa(limit))
-
-	res, err := c.userRequest(http.MethodGet, fmt.Sprintf("/shortlists/%s", shortlistID), params, nil, userToken)
-	if err != nil {
-		return PropertyList{}, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusUnauthorized {
-		return PropertyList{}, &APIError{StatusCode: res.StatusCode, Message: "unauthorized"}
-	}
-	if res.StatusCode != http.StatusOK {
-		detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
-		return PropertyList{}, fmt.Errorf("shortlist list: %s %s", res.Status, strings.TrimSpace(string(detail)))
-	}
-
-	var payload map[string]any
-	dec := json.NewDecoder(res.Body)
-	dec.UseNumber()
-	if err := dec.Decode(&payload); err != nil {
-		return PropertyList{}, err
-	}
-
-	itemsRaw := pickSlice(payload, "items")
-	props := make([]Property, 0, len(itemsRaw))
-	for _, row := range itemsRaw {
-		m, ok := row.(map[string]any)
-		if !ok {
-			continue
-		}
-		asset := pickMap(m, "asset", "Asset")
-		prop := mapAssetToProperty(asset)
-		if prop.ID == "" {
-			prop.ID = firstString(m, "asset_id", "assetId", "id")
-		}
-		prop.IsShortlisted = true
-		prop.ShortlistID = shortlistID
-		props = append(props, prop)
-	}
-
-	if v, ok := intFrom(payload, "page"); ok && v > 0 {
-		page = v
-	}
-	if v, ok := intFrom(payload, "limit"); ok && v > 0 {
-		limit = v
-	}
-
-	total := len(props)
-	if v, ok := intFrom(payload, "item_count", "total", "TotalItems"); ok && v > 0 {
-		total = v
-	}
-	pages := 1
-	if v, ok := intFrom(payload, "pages"); ok && v > 0 {
-		pages = v
-	} else if limit > 0 && total > 0 {
-		pages = int(math.Ceil(float64(total) / float64(limit)))
-	}
-
-	return PropertyList{
-		Items: props,
-		Page:  page,
-		Pages: pages,
-		Total: total,
-	}, nil
+	return c.ListShortlistItems(userToken, shortlistID, page, limit)
 }
 
 func (c *Client) getDefaultShortlistID(userToken string) (string, error) {
@@ -509,38 +437,21 @@ func (c *Client) getDefaultShortlistID(userToken string) (string, error) {
 		return mockShortlists.defaultShortlistID(), nil
 	}
 
-	res, err := c.userRequest(http.MethodGet, "/shortlists", nil, nil, userToken)
+	lists, err := c.ListShortlists(userToken)
 	if err != nil {
 		return "", err
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusUnauthorized {
-		return "", &APIError{StatusCode: res.StatusCode, Message: "unauthorized"}
-	}
-	if res.StatusCode != http.StatusOK {
-		detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
-		return "", fmt.Errorf("shortlists: %s %s", res.Status, strings.TrimSpace(string(detail)))
-	}
-
-	var rows []map[string]any
-	dec := json.NewDecoder(res.Body)
-	dec.UseNumber()
-	if err := dec.Decode(&rows); err != nil {
-		return "", err
-	}
 
 	var fallback string
-	for _, row := range rows {
-		id := firstString(row, "id", "ID")
-		if id == "" {
+	for _, l := range lists {
+		if l.ID == "" {
 			continue
 		}
 		if fallback == "" {
-			fallback = id
+			fallback = l.ID
 		}
-		if def, ok := boolFrom(row, "is_default", "isDefault"); ok && def {
-			return id, nil
+		if l.IsDefault {
+			return l.ID, nil
 		}
 	}
 
@@ -1082,6 +993,8 @@ func mapAssetToProperty(raw map[string]any) Property {
 		buildAddress(location),
 		firstString(location, "raw"),
 	)
+	prop.Neighborhood = titleize(firstString(location, "neighborhood", "area"))
+	prop.City = titleize(firstString(location, "city"))
 
 	prop.Description = firstNonEmpty(
 		firstString(details, "description", "listing_description", "listingDescription", "overview", "remarks"),
@@ -1285,17 +1198,47 @@ func parseDateTime(raw string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
+type mockShortlistItem struct {
+	addedAt time.Time
+	notes   string
+}
+
+type mockShortlist struct {
+	ID          string
+	Name        string
+	Description string
+	IsDefault   bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	items       map[string]mockShortlistItem
+}
+
+func (l *mockShortlist) toShortlist() Shortlist {
+	return Shortlist{
+		ID:          l.ID,
+		Name:        l.Name,
+		Description: l.Description,
+		IsDefault:   l.IsDefault,
+		ItemCount:   len(l.items),
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+	}
+}
+
+// mockShortlistStore models Nestlo shortlists in memory: every user gets a default
+// "Favorites" list and can create further named lists.
 type mockShortlistStore struct {
 	mu        sync.Mutex
-	items     map[string]map[string]time.Time
+	lists     map[string][]*mockShortlist
 	defaultID string
+	seq       int
 }
 
 var mockShortlists = newMockShortlistStore()
 
 func newMockShortlistStore() *mockShortlistStore {
 	store := &mockShortlistStore{
-		items:     make(map[string]map[string]time.Time),
+		lists:     make(map[string][]*mockShortlist),
 		defaultID: "mock-shortlist-favorites",
 	}
 
@@ -1306,10 +1249,13 @@ func newMockShortlistStore() *mockShortlistStore {
 		"mock-com-mohakhali-01",
 	}
 	now := time.Now()
-	store.items["demo"] = make(map[string]time.Time)
+	lists := store.ensureUser("demo")
 	for i, id := range seed {
-		store.items["demo"][id] = now.Add(-time.Duration(i) * time.Minute)
+		lists[0].items[id] = mockShortlistItem{addedAt: now.Add(-time.Duration(i) * time.Minute)}
 	}
+	office := store.newList("Office space", "Commercial options near work", now)
+	office.items["mock-com-badda-01"] = mockShortlistItem{addedAt: now, notes: "Close to the Gulshan office"}
+	store.lists["demo"] = append(store.lists["demo"], office)
 	return store
 }
 
@@ -1321,46 +1267,92 @@ func (s *mockShortlistStore) defaultShortlistID() string {
 	return s.defaultID
 }
 
-func (s *mockShortlistStore) ensureUser(token string) map[string]time.Time {
+func (s *mockShortlistStore) newList(name, description string, now time.Time) *mockShortlist {
+	s.seq++
+	return &mockShortlist{
+		ID:          fmt.Sprintf("mock-shortlist-%d", s.seq),
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		items:       make(map[string]mockShortlistItem),
+	}
+}
+
+// ensureUser returns the user's lists, creating the default one on first use. Caller holds s.mu.
+func (s *mockShortlistStore) ensureUser(token string) []*mockShortlist {
 	key := s.keyFor(token)
 	if key == "" {
 		key = "demo"
 	}
-	if s.items[key] == nil {
-		s.items[key] = make(map[string]time.Time)
+	if len(s.lists[key]) == 0 {
+		now := time.Now()
+		s.lists[key] = []*mockShortlist{{
+			ID:          s.defaultID,
+			Name:        "Favorites",
+			Description: "My favorite properties",
+			IsDefault:   true,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			items:       make(map[string]mockShortlistItem),
+		}}
 	}
-	return s.items[key]
+	return s.lists[key]
+}
+
+// find returns one of the user's lists by ID. Caller holds s.mu.
+func (s *mockShortlistStore) find(token, shortlistID string) *mockShortlist {
+	for _, l := range s.ensureUser(token) {
+		if l.ID == shortlistID {
+			return l
+		}
+	}
+	return nil
 }
 
 func (s *mockShortlistStore) status(token, assetID string) ShortlistStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	userItems := s.ensureUser(token)
-	_, ok := userItems[assetID]
+	for _, l := range s.ensureUser(token) {
+		if _, ok := l.items[assetID]; ok {
+			return ShortlistStatus{
+				AssetID:       assetID,
+				ShortlistID:   l.ID,
+				IsShortlisted: true,
+			}
+		}
+	}
 	return ShortlistStatus{
 		AssetID:       assetID,
 		ShortlistID:   s.defaultID,
-		IsShortlisted: ok,
+		IsShortlisted: false,
 	}
 }
 
 func (s *mockShortlistStore) add(token, assetID string) ShortlistStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	userItems := s.ensureUser(token)
-	userItems[assetID] = time.Now()
+	def := s.ensureUser(token)[0]
+	item, ok := def.items[assetID]
+	if !ok {
+		item.addedAt = time.Now()
+	}
+	def.items[assetID] = item
+	def.UpdatedAt = time.Now()
 	return ShortlistStatus{
 		AssetID:       assetID,
-		ShortlistID:   s.defaultID,
+		ShortlistID:   def.ID,
 		IsShortlisted: true,
 	}
 }
 
+// remove deletes assetID from every list, matching DELETE /shortlists/items/{asset_id}.
 func (s *mockShortlistStore) remove(token, assetID string) ShortlistStatus {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	userItems := s.ensureUser(token)
-	delete(userItems, assetID)
+	for _, l := range s.ensureUser(token) {
+		delete(l.items, assetID)
+	}
 	return ShortlistStatus{
 		AssetID:       assetID,
 		ShortlistID:   s.defaultID,
@@ -1369,17 +1361,123 @@ func (s *mockShortlistStore) remove(token, assetID string) ShortlistStatus {
 }
 
 func (s *mockShortlistStore) list(token string, page, limit int) PropertyList {
+	list, _ := s.listItems(token, s.defaultID, page, limit)
+	return list
+}
+
+func (s *mockShortlistStore) shortlists(token string) []Shortlist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists := s.ensureUser(token)
+	out := make([]Shortlist, 0, len(lists))
+	for _, l := range lists {
+		out = append(out, l.toShortlist())
+	}
+	return out
+}
+
+func (s *mockShortlistStore) create(token, name, description string) Shortlist {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	userItems := s.ensureUser(token)
+	key := s.keyFor(token)
+	if key == "" {
+		key = "demo"
+	}
+	s.ensureUser(token)
+	l := s.newList(name, description, time.Now())
+	s.lists[key] = append(s.lists[key], l)
+	return l.toShortlist()
+}
+
+func (s *mockShortlistStore) update(token, shortlistID, name, description string) (Shortlist, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.find(token, shortlistID)
+	if l == nil {
+		return Shortlist{}, false
+	}
+	if name != "" {
+		l.Name = name
+	}
+	if description != "" {
+		l.Description = description
+	}
+	l.UpdatedAt = time.Now()
+	return l.toShortlist(), true
+}
+
+func (s *mockShortlistStore) delete(token, shortlistID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := s.keyFor(token)
+	if key == "" {
+		key = "demo"
+	}
+	lists := s.ensureUser(token)
+	for i, l := range lists {
+		if l.ID != shortlistID {
+			continue
+		}
+		if l.IsDefault {
+			return &APIError{StatusCode: http.StatusConflict, Message: "the default shortlist cannot be deleted"}
+		}
+		s.lists[key] = append(lists[:i:i], lists[i+1:]...)
+		return nil
+	}
+	return &APIError{StatusCode: http.StatusNotFound, Message: "shortlist not found"}
+}
+
+func (s *mockShortlistStore) addTo(token, shortlistID, assetID string) (ShortlistStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.find(token, shortlistID)
+	if l == nil {
+		return ShortlistStatus{}, false
+	}
+	item, ok := l.items[assetID]
+	if !ok {
+		item.addedAt = time.Now()
+	}
+	l.items[assetID] = item
+	l.UpdatedAt = time.Now()
+	return ShortlistStatus{AssetID: assetID, ShortlistID: l.ID, IsShortlisted: true}, true
+}
+
+func (s *mockShortlistStore) removeFrom(token, shortlistID, assetID string) (ShortlistStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.find(token, shortlistID)
+	if l == nil {
+		return ShortlistStatus{}, false
+	}
+	delete(l.items, assetID)
+	l.UpdatedAt = time.Now()
+	still := false
+	for _, other := range s.ensureUser(token) {
+		if _, ok := other.items[assetID]; ok {
+			still = true
+			break
+		}
+	}
+	return ShortlistStatus{AssetID: assetID, ShortlistID: l.ID, IsShortlisted: still}, true
+}
+
+func (s *mockShortlistStore) listItems(token, shortlistID string, page, limit int) (PropertyList, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.find(token, shortlistID)
+	if l == nil {
+		return PropertyList{}, false
+	}
 
 	type record struct {
-		id   string
-		time time.Time
+		id    string
+		time  time.Time
+		notes string
 	}
-	rows := make([]record, 0, len(userItems))
-	for id, added := range userItems {
-		rows = append(rows, record{id: id, time: added})
+	rows := make([]record, 0, len(l.items))
+	for id, item := range l.items {
+		rows = append(rows, record{id: id, time: item.addedAt, notes: item.notes})
 	}
 
 	sort.SliceStable(rows, func(i, j int) bool {
@@ -1414,9 +1512,12 @@ func (s *mockShortlistStore) list(token string, page, limit int) PropertyList {
 	items := make([]Property, 0, end-start)
 	for _, row := range rows[start:end] {
 		if prop, ok := mockPropertyByID(row.id); ok {
+			prop = finalizeProperty(prop)
 			prop.IsShortlisted = true
-			prop.ShortlistID = s.defaultID
-			items = append(items, finalizeProperty(prop))
+			prop.ShortlistID = l.ID
+			prop.ShortlistNotes = row.notes
+			prop.ShortlistedAt = row.time
+			items = append(items, prop)
 		}
 	}
 
@@ -1425,7 +1526,7 @@ func (s *mockShortlistStore) list(token string, page, limit int) PropertyList {
 		Page:  page,
 		Pages: pages,
 		Total: total,
-	}
+	}, true
 }
 
 func mockRequiredDocuments(assetType string) []Document {
@@ -2581,6 +2682,43 @@ func (c *Client) GetProperty(id string) (Property, error) {
 	return prop, nil
 }
 
+// LookupProperty fetches a property without GetProperty's mock fallback, for background
+// jobs that must tell a withdrawn listing (found=false) from a transient error.
+func (c *Client) LookupProperty(id string) (prop Property, found bool, err error) {
+	if id == "" {
+		return prop, false, fmt.Errorf("property id required")
+	}
+	if c.mockEnabled {
+		if mock, ok := mockPropertyByID(id); ok {
+			return finalizeProperty(mock), true, nil
+		}
+		return prop, false, nil
+	}
+
+	res, err := c.doGet(fmt.Sprintf("/assets/%s", url.PathEscape(id)), nil)
+	if err != nil {
+		return prop, false, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return prop, false, nil
+	case res.StatusCode != http.StatusOK:
+		return prop, false, &APIError{StatusCode: res.StatusCode, Message: fmt.Sprintf("get asset: %s", res.Status)}
+	}
+	var payload map[string]any
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return prop, false, fmt.Errorf("get asset: %w", err)
+	}
+	prop = mapAssetToProperty(payload)
+	if prop.ID == "" {
+		prop.ID = id
+	}
+	return prop, true, nil
+}
+
 func (c *Client) GetRequiredDocuments(assetType string) ([]Document, error) {
 	assetType = strings.TrimSpace(strings.ToLower(assetType))
 	if assetType == "" {
@@ -2647,7 +2785,14 @@ type LeadReq struct {
 	Message      string `json:"message,omitempty"`
 	ContactEmail string `json:"contactEmail,omitempty"`
 	UTMSource    string `json:"utmSource,omitempty"`
+	UTMMedium    string `json:"utmMedium,omitempty"`
 	UTMCampaign  string `json:"utmCampaign,omitempty"`
+	UTMTerm      string `json:"utmTerm,omitempty"`
+	UTMContent   string `json:"utmContent,omitempty"`
+	GCLID        string `json:"gclid,omitempty"`
+	FBCLID       string `json:"fbclid,omitempty"`
+	Referrer     string `json:"referrer,omitempty"`
+	LandingPage  string `json:"landingPage,omitempty"`
 	CaptchaToken string `json:"captchaToken,omitempty"`
 }
 
@@ -2676,9 +2821,25 @@ type NestloLeadPayload struct {
 	Requirements *NestloLeadRequirements `json:"requirements,omitempty"`
 	Notes        string                  `json:"notes,omitempty"`
 	AssetID      string                  `json:"asset_id,omitempty"`
+	// AssignedAgentID is the Nestlo user the lead was routed to.
+	AssignedAgentID string `json:"assigned_agent_id,omitempty"`
 }
 
 func (c *Client) SubmitLead(in LeadReq) error {
+	return c.SubmitLeadWithKey(in, "")
+}
+
+// ErrOutcomeUnknown marks a lead POST that may have reached Nestlo without a usable answer
+// coming back: the connection failed after the request was sent, or a 5xx arrived with
+// an empty body. Nestlo documents neither idempotency keys nor a lookup by reference, so
+// sending it again could create a second lead.
+var ErrOutcomeUnknown = errors.New("outcome unknown")
+
+// SubmitLeadWithKey posts to /leads with an Idempotency-Key header. The Nestlo API guide
+// does not define that header, so it is a hint for their logs, not a guarantee against
+// duplicates. HTTP failures are returned as *APIError; failures that may have created the
+// lead anyway also match ErrOutcomeUnknown.
+func (c *Client) SubmitLeadWithKey(in LeadReq, idempotencyKey string) error {
 	endp := c.buildURL("/leads", nil)
 	b, _ := json.Marshal(in)
 	req, err := http.NewRequest(http.MethodPost, endp, bytes.NewReader(b))
@@ -2687,19 +2848,49 @@ func (c *Client) SubmitLead(in LeadReq) error {
 	}
 	c.decorateRequest(req)
 	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
 	res, err := c.HC.Do(req)
 	if err != nil {
-		return err
+		return leadSendError(err)
 	}
 	defer res.Body.Close()
 	if res.StatusCode >= http.StatusMultipleChoices {
-		return fmt.Errorf("lead: %s", res.Status)
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
+		return leadStatusError(res, "lead", strings.TrimSpace(string(detail)))
 	}
 	return nil
 }
 
+// leadSendError classifies a transport failure of a lead POST. Only a failed dial proves
+// the request never left; anything later (timeouts, resets) may have created the lead.
+func leadSendError(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrOutcomeUnknown, err)
+}
+
+// leadStatusError turns a non-success response into an *APIError. A 5xx with no body
+// gives no sign the lead was rejected, so it also matches ErrOutcomeUnknown.
+func leadStatusError(res *http.Response, what, detail string) error {
+	apiErr := &APIError{StatusCode: res.StatusCode, Message: strings.TrimSpace(fmt.Sprintf("%s: %s %s", what, res.Status, detail))}
+	if res.StatusCode >= http.StatusInternalServerError && detail == "" {
+		return fmt.Errorf("%w: %w", ErrOutcomeUnknown, apiErr)
+	}
+	return apiErr
+}
+
 func (c *Client) CreateNestloLead(in NestloLeadPayload) error {
+	return c.CreateNestloLeadWithKey(in, "")
+}
+
+// CreateNestloLeadWithKey posts to /admin/leads with an Idempotency-Key header. Errors are
+// reported as for SubmitLeadWithKey.
+func (c *Client) CreateNestloLeadWithKey(in NestloLeadPayload, idempotencyKey string) error {
 	if strings.TrimSpace(in.LeadType) == "" {
 		in.LeadType = "tenant"
 	}
@@ -2715,17 +2906,20 @@ func (c *Client) CreateNestloLead(in NestloLeadPayload) error {
 	}
 	c.decorateRequest(req)
 	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
 	start := time.Now()
 	res, err := c.HC.Do(req)
 	if err != nil {
-		return err
+		return leadSendError(err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusOK {
 		detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
-		return fmt.Errorf("nestlo lead: %s %s", res.Status, strings.TrimSpace(string(detail)))
+		return leadStatusError(res, "nestlo lead", strings.TrimSpace(string(detail)))
 	}
 
 	log.Printf("Nestlo lead created for asset %s in %dms", in.AssetID, time.Since(start).Milliseconds())
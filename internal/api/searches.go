@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// NormalizeSearchQuery returns the canonical /assets filters for q, without paging.
+// Saved searches store this form so every re-run asks Nestlo the same question.
+func NormalizeSearchQuery(q url.Values) url.Values {
+	params := buildAssetSearchParams(q)
+	params.Del("page")
+	params.Del("limit")
+	return params
+}
+
+// SearchAll pages through a search and returns up to max listings. Unlike SearchProperties
+// it never falls back to mock data on errors, so background jobs cannot mistake mock
+// listings for new ones.
+func (c *Client) SearchAll(q url.Values, max int) ([]Property, error) {
+	const pageSize = 50
+	if max <= 0 {
+		max = pageSize
+	}
+
+	props := make([]Property, 0, pageSize)
+	for page := 1; len(props) < max; page++ {
+		params := NormalizeSearchQuery(q)
+		params.Set("page", strconv.Itoa(page))
+		params.Set("limit", strconv.Itoa(pageSize))
+
+		var list PropertyList
+		if c.mockEnabled {
+			list = c.getMockSearchResults(params)
+		} else {
+			var err error
+			if list, err = c.searchPage(params); err != nil {
+				return props, err
+			}
+		}
+
+		props = append(props, list.Items...)
+		if len(list.Items) < pageSize || page >= list.Pages {
+			break
+		}
+	}
+	if len(props) > max {
+		props = props[:max]
+	}
+	return props, nil
+}
+
+func (c *Client) searchPage(params url.Values) (PropertyList, error) {
+	res, err := c.doGet("/assets", params)
+	if err != nil {
+		return PropertyList{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return PropertyList{}, &APIError{StatusCode: res.StatusCode, Message: fmt.Sprintf("search assets: %s", res.Status)}
+	}
+
+	var payload assetListResponse
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return PropertyList{}, fmt.Errorf("search assets: %w", err)
+	}
+
+	props := make([]Property, 0, len(payload.Data))
+	for _, asset := range payload.Data {
+		if prop := mapAssetToProperty(asset); prop.ID != "" {
+			props = append(props, prop)
+		}
+	}
+	pages := 1
+	if payload.Limit > 0 && payload.Total > payload.Limit {
+		pages = (payload.Total + payload.Limit - 1) / payload.Limit
+	}
+	return PropertyList{Items: props, Page: payload.Page, Pages: pages, Total: payload.Total}, nil
+}
@@ -0,0 +1,602 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// shortlistCheckConcurrency bounds parallel CheckShortlist calls.
+	shortlistCheckConcurrency = 6
+	// shortlistMembershipPages caps the list pages read to build a full membership snapshot;
+	// users with bigger lists get per-item checks for the cards on screen instead.
+	shortlistMembershipPages = 4
+	shortlistMembershipTTL   = 30 * time.Second
+	shortlistItemsPageSize   = 50
+	shortlistItemsMaxPages   = 20
+)
+
+// membership caches, per user token, which listed assets are shortlisted. complete
+// means saved covers every list; otherwise known holds per-item check results.
+type membership struct {
+	at       time.Time
+	complete bool
+	saved    map[string]string // asset ID -> shortlist ID
+	known    map[string]ShortlistStatus
+}
+
+var (
+	membershipMu    sync.Mutex
+	membershipCache = map[string]*membership{}
+)
+
+// Shortlist is one of a user's named Nestlo shortlists.
+type Shortlist struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	IsDefault   bool      `json:"is_default"`
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// ListShortlists returns every shortlist owned by the user, default first when Nestlo flags one.
+func (c *Client) ListShortlists(userToken string) ([]Shortlist, error) {
+	if c.mockEnabled {
+		return mockShortlists.shortlists(userToken), nil
+	}
+
+	res, err := c.userRequest(http.MethodGet, "/shortlists", nil, nil, userToken)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlists", http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]any
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	lists := make([]Shortlist, 0, len(rows))
+	for _, row := range rows {
+		l := mapShortlist(row)
+		if l.ID == "" {
+			continue
+		}
+		if l.IsDefault {
+			lists = append([]Shortlist{l}, lists...)
+			continue
+		}
+		lists = append(lists, l)
+	}
+	return lists, nil
+}
+
+// CreateShortlist creates a new named shortlist for the user.
+func (c *Client) CreateShortlist(userToken, name, description string) (Shortlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Shortlist{}, fmt.Errorf("shortlist name is required")
+	}
+
+	if c.mockEnabled {
+		return mockShortlists.create(userToken, name, strings.TrimSpace(description)), nil
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"name":        name,
+		"description": strings.TrimSpace(description),
+	})
+	res, err := c.userJSONRequest(http.MethodPost, "/shortlists", body, userToken)
+	if err != nil {
+		return Shortlist{}, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlist create", http.StatusCreated, http.StatusOK); err != nil {
+		return Shortlist{}, err
+	}
+	return decodeShortlist(res.Body, Shortlist{Name: name, Description: description})
+}
+
+// RenameShortlist updates a shortlist's name and (optionally) description.
+func (c *Client) RenameShortlist(userToken, shortlistID, name, description string) (Shortlist, error) {
+	shortlistID = strings.TrimSpace(shortlistID)
+	name = strings.TrimSpace(name)
+	if shortlistID == "" || name == "" {
+		return Shortlist{}, fmt.Errorf("shortlist id and name are required")
+	}
+
+	if c.mockEnabled {
+		l, ok := mockShortlists.update(userToken, shortlistID, name, strings.TrimSpace(description))
+		if !ok {
+			return Shortlist{}, &APIError{StatusCode: http.StatusNotFound, Message: "shortlist not found"}
+		}
+		return l, nil
+	}
+
+	payload := map[string]string{"name": name}
+	if d := strings.TrimSpace(description); d != "" {
+		payload["description"] = d
+	}
+	body, _ := json.Marshal(payload)
+	res, err := c.userJSONRequest(http.MethodPatch, "/shortlists/"+url.PathEscape(shortlistID), body, userToken)
+	if err != nil {
+		return Shortlist{}, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlist rename", http.StatusOK); err != nil {
+		return Shortlist{}, err
+	}
+	return decodeShortlist(res.Body, Shortlist{ID: shortlistID, Name: name, Description: description})
+}
+
+// DeleteShortlist removes a non-default shortlist and its items.
+func (c *Client) DeleteShortlist(userToken, shortlistID string) error {
+	defer forgetMembership(userToken)
+	shortlistID = strings.TrimSpace(shortlistID)
+	if shortlistID == "" {
+		return fmt.Errorf("shortlist id is required")
+	}
+
+	if c.mockEnabled {
+		return mockShortlists.delete(userToken, shortlistID)
+	}
+
+	res, err := c.userRequest(http.MethodDelete, "/shortlists/"+url.PathEscape(shortlistID), nil, nil, userToken)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return shortlistResponseError(res, "shortlist delete", http.StatusOK, http.StatusNoContent)
+}
+
+// AddToNamedShortlist adds a property to a specific shortlist.
+func (c *Client) AddToNamedShortlist(userToken, shortlistID, assetID string) (ShortlistStatus, error) {
+	defer forgetMembership(userToken)
+	shortlistID = strings.TrimSpace(shortlistID)
+	assetID = strings.TrimSpace(assetID)
+	if shortlistID == "" || assetID == "" {
+		return ShortlistStatus{}, fmt.Errorf("shortlist id and asset id are required")
+	}
+
+	if c.mockEnabled {
+		status, ok := mockShortlists.addTo(userToken, shortlistID, assetID)
+		if !ok {
+			return ShortlistStatus{}, &APIError{StatusCode: http.StatusNotFound, Message: "shortlist not found"}
+		}
+		return status, nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"asset_id": assetID})
+	res, err := c.userJSONRequest(http.MethodPost, fmt.Sprintf("/shortlists/%s/items", url.PathEscape(shortlistID)), body, userToken)
+	if err != nil {
+		return ShortlistStatus{}, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlist add", http.StatusCreated, http.StatusOK); err != nil {
+		return ShortlistStatus{}, err
+	}
+	return ShortlistStatus{AssetID: assetID, ShortlistID: shortlistID, IsShortlisted: true}, nil
+}
+
+// RemoveFromNamedShortlist removes a property from one shortlist, leaving the others untouched.
+func (c *Client) RemoveFromNamedShortlist(userToken, shortlistID, assetID string) (ShortlistStatus, error) {
+	defer forgetMembership(userToken)
+	shortlistID = strings.TrimSpace(shortlistID)
+	assetID = strings.TrimSpace(assetID)
+	if shortlistID == "" || assetID == "" {
+		return ShortlistStatus{}, fmt.Errorf("shortlist id and asset id are required")
+	}
+
+	if c.mockEnabled {
+		status, ok := mockShortlists.removeFrom(userToken, shortlistID, assetID)
+		if !ok {
+			return ShortlistStatus{}, &APIError{StatusCode: http.StatusNotFound, Message: "shortlist not found"}
+		}
+		return status, nil
+	}
+
+	path := fmt.Sprintf("/shortlists/%s/items/%s", url.PathEscape(shortlistID), url.PathEscape(assetID))
+	res, err := c.userRequest(http.MethodDelete, path, nil, nil, userToken)
+	if err != nil {
+		return ShortlistStatus{}, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlist remove", http.StatusOK, http.StatusNoContent); err != nil {
+		return ShortlistStatus{}, err
+	}
+	return ShortlistStatus{AssetID: assetID, ShortlistID: shortlistID, IsShortlisted: false}, nil
+}
+
+// MoveShortlistItem moves a property between two of the user's shortlists.
+// It adds to the target first so a failure never loses the item.
+func (c *Client) MoveShortlistItem(userToken, fromID, toID, assetID string) (ShortlistStatus, error) {
+	if strings.TrimSpace(fromID) == strings.TrimSpace(toID) {
+		return ShortlistStatus{AssetID: assetID, ShortlistID: toID, IsShortlisted: true}, nil
+	}
+	status, err := c.AddToNamedShortlist(userToken, toID, assetID)
+	if err != nil {
+		return ShortlistStatus{}, err
+	}
+	if _, err := c.RemoveFromNamedShortlist(userToken, fromID, assetID); err != nil {
+		return status, fmt.Errorf("shortlist move: added to %s but could not remove from %s: %w", toID, fromID, err)
+	}
+	return status, nil
+}
+
+// ListShortlistItems fetches one page of a specific shortlist with full property details.
+func (c *Client) ListShortlistItems(userToken, shortlistID string, page, limit int) (PropertyList, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 9
+	}
+
+	if c.mockEnabled {
+		list, ok := mockShortlists.listItems(userToken, shortlistID, page, limit)
+		if !ok {
+			return PropertyList{}, &APIError{StatusCode: http.StatusNotFound, Message: "shortlist not found"}
+		}
+		return list, nil
+	}
+
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	params.Set("limit", strconv.Itoa(limit))
+
+	res, err := c.userRequest(http.MethodGet, "/shortlists/"+url.PathEscape(shortlistID), params, nil, userToken)
+	if err != nil {
+		return PropertyList{}, err
+	}
+	defer res.Body.Close()
+
+	if err := shortlistResponseError(res, "shortlist list", http.StatusOK); err != nil {
+		return PropertyList{}, err
+	}
+
+	var payload map[string]any
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return PropertyList{}, err
+	}
+
+	itemsRaw := pickSlice(payload, "items")
+	props := make([]Property, 0, len(itemsRaw))
+	for _, row := range itemsRaw {
+		m, ok := row.(map[string]any)
+		if !ok {
+			continue
+		}
+		asset := pickMap(m, "asset", "Asset")
+		prop := mapAssetToProperty(asset)
+		if prop.ID == "" {
+			prop.ID = firstString(m, "asset_id", "assetId", "id")
+		}
+		prop.IsShortlisted = true
+		prop.ShortlistID = shortlistID
+		prop.ShortlistNotes = firstString(m, "notes", "Notes")
+		if t, ok := parseDateTime(firstString(m, "added_at", "addedAt")); ok {
+			prop.ShortlistedAt = t
+		}
+		props = append(props, prop)
+	}
+
+	if v, ok := intFrom(payload, "page"); ok && v > 0 {
+		page = v
+	}
+	if v, ok := intFrom(payload, "limit"); ok && v > 0 {
+		limit = v
+	}
+
+	total := len(props)
+	if v, ok := intFrom(payload, "item_count", "total", "TotalItems"); ok && v > 0 {
+		total = v
+	}
+	pages := 1
+	if v, ok := intFrom(payload, "pages"); ok && v > 0 {
+		pages = v
+	} else if limit > 0 && total > 0 {
+		pages = int(math.Ceil(float64(total) / float64(limit)))
+	}
+
+	return PropertyList{
+		Items: props,
+		Page:  page,
+		Pages: pages,
+		Total: total,
+	}, nil
+}
+
+// CheckShortlistBatch reports shortlist membership for many assets at once. Nestlo has no
+// bulk check, so one GET /shortlists sizes the user's lists: small lists are read whole
+// (at most shortlistMembershipPages pages), larger ones fall back to concurrent
+// CheckShortlist calls for just the requested assets. Results are cached per user for
+// shortlistMembershipTTL and dropped when the user changes a list.
+func (c *Client) CheckShortlistBatch(userToken string, assetIDs []string) (map[string]ShortlistStatus, error) {
+	ids := make([]string, 0, len(assetIDs))
+	seen := make(map[string]struct{}, len(assetIDs))
+	for _, id := range assetIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	out := make(map[string]ShortlistStatus, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+
+	if c.mockEnabled {
+		for _, id := range ids {
+			out[id] = c.mockCheckShortlist(id, userToken)
+		}
+		return out, nil
+	}
+
+	m := cachedMembership(userToken)
+	if m == nil {
+		var err error
+		if m, err = c.loadMembership(userToken); err != nil {
+			return nil, err
+		}
+		storeMembership(userToken, m)
+	}
+
+	if m.complete {
+		for _, id := range ids {
+			status := ShortlistStatus{AssetID: id}
+			if listID, ok := m.saved[id]; ok {
+				status.ShortlistID = listID
+				status.IsShortlisted = true
+			}
+			out[id] = status
+		}
+		return out, nil
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if status, ok := m.known[id]; ok {
+			out[id] = status
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		checked, err := c.checkShortlistConcurrent(userToken, missing)
+		if err != nil {
+			return nil, err
+		}
+		// cached entries are shared between requests, so extend a copy
+		next := &membership{at: m.at, known: maps.Clone(m.known)}
+		for id, status := range checked {
+			out[id] = status
+			next.known[id] = status
+		}
+		storeMembership(userToken, next)
+	}
+	return out, nil
+}
+
+// loadMembership reads the user's lists whole when they fit in shortlistMembershipPages
+// pages, and otherwise returns an empty per-item cache.
+func (c *Client) loadMembership(userToken string) (*membership, error) {
+	lists, err := c.ListShortlists(userToken)
+	if err != nil {
+		return nil, err
+	}
+	pages := 0
+	for _, l := range lists {
+		pages += int(math.Ceil(float64(l.ItemCount) / shortlistItemsPageSize))
+	}
+	m := &membership{at: time.Now(), known: make(map[string]ShortlistStatus)}
+	if pages > shortlistMembershipPages {
+		log.Printf("shortlist batch: %d list pages exceed the snapshot limit of %d; checking items one by one", pages, shortlistMembershipPages)
+		return m, nil
+	}
+
+	m.saved = make(map[string]string)
+	for _, l := range lists {
+		items, err := c.ListAllShortlistItems(userToken, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range items {
+			if _, ok := m.saved[p.ID]; !ok {
+				m.saved[p.ID] = l.ID
+			}
+		}
+	}
+	m.complete = true
+	return m, nil
+}
+
+func cachedMembership(userToken string) *membership {
+	membershipMu.Lock()
+	defer membershipMu.Unlock()
+	m, ok := membershipCache[userToken]
+	if !ok || time.Since(m.at) > shortlistMembershipTTL {
+		return nil
+	}
+	return m
+}
+
+func storeMembership(userToken string, m *membership) {
+	membershipMu.Lock()
+	defer membershipMu.Unlock()
+	for token, old := range membershipCache {
+		if time.Since(old.at) > shortlistMembershipTTL {
+			delete(membershipCache, token)
+		}
+	}
+	membershipCache[userToken] = m
+}
+
+// forgetMembership drops the cached membership after the user changes a list.
+func forgetMembership(userToken string) {
+	membershipMu.Lock()
+	defer membershipMu.Unlock()
+	delete(membershipCache, userToken)
+}
+
+func (c *Client) checkShortlistConcurrent(userToken string, ids []string) (map[string]ShortlistStatus, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	out := make(map[string]ShortlistStatus, len(ids))
+	sem := make(chan struct{}, shortlistCheckConcurrency)
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			status, err := c.CheckShortlist(id, userToken)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			out[id] = status
+		}(id)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// ListAllShortlistItems walks every page of a shortlist. An empty shortlistID means the default list.
+// Lists longer than shortlistItemsMaxPages pages are cut off, with a log line.
+func (c *Client) ListAllShortlistItems(userToken, shortlistID string) ([]Property, error) {
+	items := make([]Property, 0)
+	for page := 1; ; page++ {
+		var list PropertyList
+		var err error
+		if strings.TrimSpace(shortlistID) == "" {
+			list, err = c.ListShortlisted(userToken, page, shortlistItemsPageSize)
+		} else {
+			list, err = c.ListShortlistItems(userToken, shortlistID, page, shortlistItemsPageSize)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+		if page >= list.Pages || len(list.Items) == 0 {
+			break
+		}
+		if page == shortlistItemsMaxPages {
+			log.Printf("shortlist %q: truncated at %d items of %d", shortlistID, len(items), list.Total)
+			break
+		}
+	}
+	return items, nil
+}
+
+func (c *Client) userJSONRequest(method, path string, body []byte, userToken string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.buildURL(path, nil), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.decorateUserRequest(req, userToken); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.HC.Do(req)
+}
+
+// shortlistResponseError maps non-success statuses to errors, keeping 401/404/409 as APIError
+// so handlers can react to them.
+func shortlistResponseError(res *http.Response, op string, ok ...int) error {
+	for _, code := range ok {
+		if res.StatusCode == code {
+			return nil
+		}
+	}
+	detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
+	msg := strings.TrimSpace(string(detail))
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		return &APIError{StatusCode: res.StatusCode, Message: "unauthorized"}
+	case http.StatusNotFound, http.StatusConflict, http.StatusBadRequest:
+		if msg == "" {
+			msg = http.StatusText(res.StatusCode)
+		}
+		return &APIError{StatusCode: res.StatusCode, Message: msg}
+	}
+	return fmt.Errorf("%s: %s %s", op, res.Status, msg)
+}
+
+func decodeShortlist(body io.Reader, fallback Shortlist) (Shortlist, error) {
+	var row map[string]any
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	if err := dec.Decode(&row); err != nil {
+		return fallback, nil
+	}
+	l := mapShortlist(row)
+	if l.ID == "" {
+		l.ID = fallback.ID
+	}
+	if l.Name == "" {
+		l.Name = fallback.Name
+	}
+	return l, nil
+}
+
+func mapShortlist(row map[string]any) Shortlist {
+	l := Shortlist{
+		ID:          firstString(row, "id", "ID", "shortlist_id"),
+		Name:        firstString(row, "name", "Name"),
+		Description: firstString(row, "description", "Description"),
+	}
+	if def, ok := boolFrom(row, "is_default", "isDefault"); ok {
+		l.IsDefault = def
+	}
+	if n, ok := intFrom(row, "item_count", "itemCount"); ok {
+		l.ItemCount = n
+	}
+	if t, ok := parseDateTime(firstString(row, "created_at", "createdAt")); ok {
+		l.CreatedAt = t
+	}
+	if t, ok := parseDateTime(firstString(row, "updated_at", "updatedAt")); ok {
+		l.UpdatedAt = t
+	}
+	return l
+}
@@ -0,0 +1,245 @@
+// Package attribution remembers where a visitor came from (UTM tags, ad click IDs and
+// the first external referrer) so leads can be credited to the campaign that earned them.
+package attribution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+)
+
+const (
+	cookieName = "dh_attr"
+	cookieAge  = 90 * 24 * time.Hour
+	maxField   = 200
+)
+
+// Touch is what we know about how a visitor arrived. The referrer and landing page are
+// first-touch; campaign fields are replaced whenever the visitor returns through a new
+// tagged link. Times are Unix seconds so an unset one drops out of the cookie.
+type Touch struct {
+	Source      string `json:"src,omitempty"`
+	Medium      string `json:"med,omitempty"`
+	Campaign    string `json:"cmp,omitempty"`
+	Term        string `json:"trm,omitempty"`
+	Content     string `json:"cnt,omitempty"`
+	GCLID       string `json:"gclid,omitempty"`
+	FBCLID      string `json:"fbclid,omitempty"`
+	Referrer    string `json:"ref,omitempty"`
+	LandingPage string `json:"land,omitempty"`
+	FirstSeen   int64  `json:"first,omitempty"`
+	CampaignAt  int64  `json:"cmpAt,omitempty"`
+}
+
+// Empty reports whether nothing was captured.
+func (t Touch) Empty() bool { return t == Touch{} }
+
+// SourceLabel is the reporting source: utm_source, the ad network behind a click ID,
+// the referring host, or "direct".
+func (t Touch) SourceLabel() string {
+	switch {
+	case t.Source != "":
+		return t.Source
+	case t.GCLID != "":
+		return "google"
+	case t.FBCLID != "":
+		return "facebook"
+	case t.Referrer != "":
+		return referrerHost(t.Referrer)
+	}
+	return "direct"
+}
+
+// MediumLabel is utm_medium, or a medium inferred the way analytics tools usually do.
+func (t Touch) MediumLabel() string {
+	switch {
+	case t.Medium != "":
+		return t.Medium
+	case t.GCLID != "", t.FBCLID != "":
+		return "cpc"
+	case t.Referrer != "" && isSearchEngine(referrerHost(t.Referrer)):
+		return "organic"
+	case t.Referrer != "":
+		return "referral"
+	}
+	return "(none)"
+}
+
+// NestloSource maps the touch onto Nestlo's lead source enum
+// (web, digital_media, physical_ad, referral, internal).
+func (t Touch) NestloSource() string {
+	medium := strings.ToLower(t.MediumLabel())
+	switch {
+	case t.GCLID != "", t.FBCLID != "":
+		return "digital_media"
+	case containsAny(medium, "print", "offline", "flyer", "billboard", "qr", "signboard", "newspaper"):
+		return "physical_ad"
+	case containsAny(medium, "cpc", "ppc", "paid", "display", "social", "email", "sms", "video", "banner"):
+		return "digital_media"
+	case medium == "referral":
+		return "referral"
+	}
+	return "web"
+}
+
+// Summary renders the touch as one line for lead notes.
+func (t Touch) Summary() string {
+	if t.Empty() {
+		return ""
+	}
+	parts := []string{"source " + t.SourceLabel() + " / " + t.MediumLabel()}
+	if t.Campaign != "" {
+		parts = append(parts, "campaign "+t.Campaign)
+	}
+	if t.Term != "" {
+		parts = append(parts, "term "+t.Term)
+	}
+	if t.Content != "" {
+		parts = append(parts, "content "+t.Content)
+	}
+	if t.GCLID != "" {
+		parts = append(parts, "gclid "+t.GCLID)
+	}
+	if t.FBCLID != "" {
+		parts = append(parts, "fbclid "+t.FBCLID)
+	}
+	if t.Referrer != "" {
+		parts = append(parts, "referrer "+t.Referrer)
+	}
+	if t.LandingPage != "" {
+		parts = append(parts, "landed on "+t.LandingPage)
+	}
+	return strings.Join(parts, "; ")
+}
+
+type ctxKey struct{}
+
+// FromRequest returns the visitor's touch, including anything captured on this very request.
+func FromRequest(r *http.Request) Touch {
+	if t, ok := r.Context().Value(ctxKey{}).(Touch); ok {
+		return t
+	}
+	return readCookie(r)
+}
+
+// Middleware records campaign parameters and the first external referrer on page loads.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || skipPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		current := readCookie(r)
+		updated := capture(current, r, time.Now().UTC())
+		if updated != current {
+			if b, err := json.Marshal(updated); err == nil {
+				session.SetSigned(w, r, cookieName, string(b), cookieAge)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, updated)))
+	})
+}
+
+func capture(t Touch, r *http.Request, now time.Time) Touch {
+	q := r.URL.Query()
+	campaign := Touch{
+		Source:   clip(q.Get("utm_source")),
+		Medium:   clip(q.Get("utm_medium")),
+		Campaign: clip(q.Get("utm_campaign")),
+		Term:     clip(q.Get("utm_term")),
+		Content:  clip(q.Get("utm_content")),
+		GCLID:    clip(q.Get("gclid")),
+		FBCLID:   clip(q.Get("fbclid")),
+	}
+	if !campaign.Empty() {
+		t.Source, t.Medium, t.Campaign = campaign.Source, campaign.Medium, campaign.Campaign
+		t.Term, t.Content = campaign.Term, campaign.Content
+		t.GCLID, t.FBCLID = campaign.GCLID, campaign.FBCLID
+		t.CampaignAt = now.Unix()
+	}
+
+	if t.FirstSeen == 0 {
+		t.FirstSeen = now.Unix()
+		t.LandingPage = clip(r.URL.Path)
+		if ref := externalReferrer(r); ref != "" {
+			t.Referrer = ref
+		}
+	}
+	return t
+}
+
+func readCookie(r *http.Request) Touch {
+	raw, ok := session.GetSigned(r, cookieName)
+	if !ok {
+		return Touch{}
+	}
+	var t Touch
+	if err := json.Unmarshal([]byte(raw), &t); err != nil {
+		return Touch{}
+	}
+	return t
+}
+
+// externalReferrer returns the Referer without its query string, ignoring our own pages.
+func externalReferrer(r *http.Request) string {
+	u, err := url.Parse(strings.TrimSpace(r.Referer()))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if strings.EqualFold(u.Hostname(), hostOnly(r.Host)) {
+		return ""
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return clip(u.String())
+}
+
+func skipPath(p string) bool {
+	for _, prefix := range []string{"/assets/", "/api/", "/healthz", "/favicon", "/apple-touch-icon", "/robots.txt"} {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func referrerHost(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return ref
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func isSearchEngine(host string) bool {
+	return containsAny(host, "google.", "bing.", "duckduckgo.", "yahoo.", "yandex.", "baidu.", "ecosia.")
+}
+
+func hostOnly(hostport string) string {
+	if h, _, ok := strings.Cut(hostport, ":"); ok {
+		return h
+	}
+	return hostport
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func clip(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxField {
+		s = s[:maxField]
+	}
+	return s
+}
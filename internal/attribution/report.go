@@ -0,0 +1,210 @@
+package attribution
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Record is one delivered lead, or a lightweight lead event such as a WhatsApp
+// click, credited to a touch.
+type Record struct {
+	At         time.Time `json:"at"`
+	LeadID     string    `json:"leadId"`
+	Form       string    `json:"form"`
+	Source     string    `json:"source"`
+	Medium     string    `json:"medium"`
+	Campaign   string    `json:"campaign,omitempty"`
+	Referrer   string    `json:"referrer,omitempty"`
+	PropertyID string    `json:"propertyId,omitempty"`
+	// Placement is where on the site the event started, e.g. "card" or "property".
+	Placement string `json:"placement,omitempty"`
+}
+
+// NewRecord credits leadID to t.
+func NewRecord(leadID, form string, t Touch) Record {
+	rec := Record{
+		At:       time.Now().UTC(),
+		LeadID:   leadID,
+		Form:     form,
+		Source:   t.SourceLabel(),
+		Medium:   t.MediumLabel(),
+		Campaign: t.Campaign,
+	}
+	if t.Referrer != "" {
+		rec.Referrer = referrerHost(t.Referrer)
+	}
+	return rec
+}
+
+// Log keeps lead attribution records for the local analytics view.
+type Log interface {
+	Append(rec Record) error
+	Since(t time.Time) ([]Record, error)
+}
+
+// NewLogFromEnv appends to LEAD_ATTRIBUTION_PATH (default data/lead-attribution.jsonl).
+// "memory" keeps records in process only.
+func NewLogFromEnv() Log {
+	path := config.Get().Stores.LeadAttribution
+	if path == "" {
+		return &MemoryLog{}
+	}
+	return &FileLog{Path: path}
+}
+
+// FileLog stores records as JSON lines.
+type FileLog struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (l *FileLog) Append(rec Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("attribution log: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o755); err != nil {
+		return fmt.Errorf("attribution log: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("attribution log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("attribution log: %w", err)
+	}
+	return nil
+}
+
+func (l *FileLog) Since(t time.Time) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("attribution log: %w", err)
+	}
+	defer f.Close()
+
+	var out []Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue // skip a torn last line
+		}
+		if !rec.At.Before(t) {
+			out = append(out, rec)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("attribution log: %w", err)
+	}
+	return out, nil
+}
+
+// MemoryLog keeps records in process; useful for tests and throwaway environments.
+type MemoryLog struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (l *MemoryLog) Append(rec Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, rec)
+	return nil
+}
+
+func (l *MemoryLog) Since(t time.Time) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []Record
+	for _, rec := range l.records {
+		if !rec.At.Before(t) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// Row is one line of the lead-source report.
+type Row struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign,omitempty"`
+	Leads    int    `json:"leads"`
+	Share    int    `json:"share"` // percent of all leads in the period
+}
+
+// Summarize counts leads by source, medium and campaign, busiest first.
+func Summarize(records []Record) []Row {
+	type key struct{ source, medium, campaign string }
+	counts := map[key]int{}
+	for _, rec := range records {
+		counts[key{rec.Source, rec.Medium, rec.Campaign}]++
+	}
+
+	rows := make([]Row, 0, len(counts))
+	for k, n := range counts {
+		rows = append(rows, Row{Source: k.source, Medium: k.medium, Campaign: k.campaign, Leads: n})
+	}
+	for i := range rows {
+		rows[i].Share = rows[i].Leads * 100 / len(records)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Leads != rows[j].Leads {
+			return rows[i].Leads > rows[j].Leads
+		}
+		if rows[i].Source != rows[j].Source {
+			return rows[i].Source < rows[j].Source
+		}
+		if rows[i].Medium != rows[j].Medium {
+			return rows[i].Medium < rows[j].Medium
+		}
+		return rows[i].Campaign < rows[j].Campaign
+	})
+	return rows
+}
+
+// Channel counts leads for one form or event type, such as "lead" or "whatsapp".
+type Channel struct {
+	Form  string `json:"form"`
+	Leads int    `json:"leads"`
+	Share int    `json:"share"`
+}
+
+// SummarizeChannels counts leads by form, busiest first.
+func SummarizeChannels(records []Record) []Channel {
+	counts := map[string]int{}
+	for _, rec := range records {
+		counts[rec.Form]++
+	}
+	out := make([]Channel, 0, len(counts))
+	for form, n := range counts {
+		out = append(out, Channel{Form: form, Leads: n, Share: n * 100 / len(records)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Leads != out[j].Leads {
+			return out[i].Leads > out[j].Leads
+		}
+		return out[i].Form < out[j].Form
+	})
+	return out
+}
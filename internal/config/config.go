@@ -0,0 +1,760 @@
+// Package config loads the site's settings from the environment and .env files into a
+// typed Config, checks the values each ENVIRONMENT needs, and prints a redacted summary
+// at startup. Other packages read settings through Get rather than the environment, so
+// every variable is parsed and validated here once.
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+	"github.com/joho/godotenv"
+)
+
+// Config is the site configuration.
+type Config struct {
+	// Environment is ENVIRONMENT lower-cased: local (the default), staging, uat or production.
+	Environment string
+	// EnvFile is the env file that was loaded; empty when none was.
+	EnvFile string
+
+	Addr           string
+	PublicSiteURL  string // no trailing slash
+	GetStartedURL  string
+	CookieSecret   string
+	AnalyticsToken string
+	SessionTTL     time.Duration
+
+	HTTP       HTTP
+	API        API
+	Mock       Mock
+	Contact    Contact
+	Map        Map
+	Mail       Mail
+	SMS        SMS
+	Notify     Notify
+	Captcha    Captcha
+	OTP        OTP
+	Leads      Leads
+	RateLimits RateLimits
+	Shortlists Shortlists
+	Alerts     Alerts
+	Stores     Stores
+
+	problems []string
+}
+
+// HTTP holds the server, middleware and shutdown settings.
+type HTTP struct {
+	TrustProxyHeaders  bool
+	CORSAllowedOrigins string // comma-separated
+	MaxBodyBytes       int64
+	RouteTimeout       time.Duration
+	ExportTimeout      time.Duration
+	CSPMode            string // enforce, report-only or off
+	HSTSMaxAge         time.Duration
+	ShutdownReadyDelay time.Duration
+	ShutdownTimeout    time.Duration
+}
+
+// API holds the Nestlo API endpoint and credentials.
+type API struct {
+	BaseURL      string
+	AuthToken    string // static bearer token, used instead of OAuth when set
+	ClientID     string
+	ClientSecret string
+	TokenScope   string
+	AuthURL      string
+}
+
+// Mock switches property data, and optionally login, to the built-in mock service.
+type Mock struct {
+	Enabled     bool
+	AuthEnabled bool
+}
+
+// Contact holds the fallback contact details shown when no routing rule matches.
+type Contact struct {
+	Email        string
+	EnquiryEmail string
+	PhoneRent    string
+	PhoneSales   string
+}
+
+// Map configures the Mapbox map on the properties page.
+type Map struct {
+	MapboxToken string
+	MapboxStyle string
+	DefaultLat  float64
+	DefaultLng  float64
+	DefaultZoom float64
+}
+
+// Mail configures staff and customer email. Provider is maildir (the default) or smtp.
+type Mail struct {
+	Provider    string
+	From        string // MAIL_FROM, else SMTP_FROM, else the DhakaHome info address
+	MaildirPath string
+	// RedirectTo sends every message to these addresses instead, outside production too.
+	RedirectTo []string
+	// StaffTo receives lead alerts instead of the routed inbox when set.
+	StaffTo []string
+	SMTP    SMTP
+}
+
+// SMTP is the relay used by the smtp mail provider.
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// Addr is host:port for net/smtp.
+func (s SMTP) Addr() string { return s.Host + ":" + s.Port }
+
+// SMS configures text messages. Provider is console (the default), file or http.
+type SMS struct {
+	Provider   string
+	OutboxPath string // file provider
+	GatewayURL string // http provider
+	APIKey     string
+	SenderID   string
+}
+
+// Notify configures saved-search, watch and viewing notifications. Provider is outbox
+// (the default, a local file) or live (the mailer for email, SMS_PROVIDER for texts).
+type Notify struct {
+	Provider   string
+	OutboxPath string
+}
+
+// Captcha configures the lead form challenge. An empty Provider disables it.
+type Captcha struct {
+	Provider  string // turnstile, hcaptcha, recaptcha or fake
+	SiteKey   string
+	SecretKey string
+	MinScore  float64
+}
+
+// OTP configures phone login codes.
+type OTP struct {
+	CodeLength     int
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+	MaxSendsPerDay int
+}
+
+// Leads configures lead screening, duplicate suppression and the delivery outbox.
+type Leads struct {
+	DedupeWindow      time.Duration // 0 disables
+	MinFillTime       time.Duration
+	SpamThreshold     int
+	OutboxMaxAttempts int
+	OutboxBackoff     time.Duration // first retry delay, doubling up to an hour
+	OutboxPoll        time.Duration
+}
+
+// RateLimit allows Limit requests per Window; the zero value disables the rule.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimits are the form throttles, set as "N/duration" (e.g. "10/10m") or "off".
+type RateLimits struct {
+	LeadPerIP       RateLimit
+	LeadPerContact  RateLimit
+	LoginPerIP      RateLimit
+	LoginPerAccount RateLimit
+	OTPPerIP        RateLimit
+}
+
+// Shortlists configures guest shortlists and share links.
+type Shortlists struct {
+	GuestMax int
+	ShareTTL time.Duration
+}
+
+// Alerts sets how often saved searches and property watches are checked.
+type Alerts struct {
+	SavedSearchInterval   time.Duration
+	PropertyWatchInterval time.Duration
+}
+
+// Stores holds the data file locations. Stores that can run without a file have an
+// empty path when their *_PATH is "memory".
+type Stores struct {
+	LeadOutbox          string
+	LeadQuarantine      string
+	LeadAttribution     string
+	LeadRouting         string
+	SavedSearches       string
+	PropertyWatches     string
+	ShortlistMeta       string
+	ShortlistShares     string
+	ViewingBookings     string
+	ViewingAvailability string
+}
+
+var (
+	mu      sync.Mutex
+	current *Config
+)
+
+// Load reads the env file, resolves *_FILE secrets and builds the Config that Get returns
+// from then on. The env file is ENV_FILE, else .env.local when present, else .env;
+// variables already set in the environment win over the file. The returned error lists
+// every problem found; the Config is usable either way.
+func Load() (*Config, error) {
+	envFile := os.Getenv("ENV_FILE")
+	if envFile == "" {
+		// .env.local first, for the VS Code launch configs
+		if _, err := os.Stat(".env.local"); err == nil {
+			envFile = ".env.local"
+		} else {
+			envFile = ".env"
+		}
+	}
+	loaded := ""
+	if err := godotenv.Load(envFile); err != nil {
+		log.Printf("warning: could not load %s: %v", envFile, err)
+	} else {
+		loaded = envFile
+	}
+
+	problems := resolveFiles()
+	cfg := fromEnv()
+	cfg.EnvFile = loaded
+	cfg.problems = append(problems, cfg.problems...)
+	cfg.problems = append(cfg.problems, cfg.validate()...)
+
+	mu.Lock()
+	current = cfg
+	mu.Unlock()
+
+	if loaded != "" {
+		log.Printf("✅ Loaded environment: %s (from %s)", cfg.Environment, loaded)
+	}
+	return cfg, cfg.Err()
+}
+
+// Get returns the loaded Config. Without a prior Load (tools such as export-static) it is
+// built from the current environment on first use.
+func Get() *Config {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = fromEnv()
+	}
+	return current
+}
+
+// Err reports the problems found while loading, or nil.
+func (c *Config) Err() error {
+	if len(c.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration for %s:\n  - %s", c.Environment, strings.Join(c.problems, "\n  - "))
+}
+
+// IsProduction reports whether ENVIRONMENT is production.
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production" || c.Environment == "prod"
+}
+
+// SiteURL is the public base URL for links in emails, SMS and exports: PUBLIC_SITE_URL,
+// or on a developer machine without one, this server on localhost.
+func (c *Config) SiteURL() string {
+	if c.PublicSiteURL != "" {
+		return c.PublicSiteURL
+	}
+	if strings.HasPrefix(c.Addr, ":") {
+		return "http://localhost" + c.Addr
+	}
+	return "http://" + c.Addr
+}
+
+// IsLocal reports whether this is a developer machine.
+func (c *Config) IsLocal() bool {
+	switch c.Environment {
+	case "local", "development", "dev":
+		return true
+	}
+	return false
+}
+
+// resolveFiles sets X from the file named by X_FILE (Docker and Kubernetes secrets) for
+// every such variable, so all readers of X see the secret. Trailing newlines are dropped.
+func resolveFiles() []string {
+	var problems []string
+	for _, kv := range os.Environ() {
+		name, path, _ := strings.Cut(kv, "=")
+		key, ok := strings.CutSuffix(name, "_FILE")
+		if !ok || key == "" || name == "ENV_FILE" || strings.TrimSpace(path) == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			problems = append(problems, fmt.Sprintf("%s and %s are both set; use one", key, name))
+			continue
+		}
+		b, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		os.Setenv(key, strings.TrimRight(string(b), "\r\n"))
+	}
+	return problems
+}
+
+// fromEnv builds a Config from the environment, recording values that do not parse.
+func fromEnv() *Config {
+	c := &Config{}
+	c.Environment = strings.ToLower(str("ENVIRONMENT", "local"))
+	c.Addr = str("ADDR", ":5173")
+	c.PublicSiteURL = strings.TrimRight(str("PUBLIC_SITE_URL", ""), "/")
+	c.GetStartedURL = str("GET_STARTED_URL", "/requirements")
+	c.CookieSecret = str("COOKIE_SECRET", "")
+	c.AnalyticsToken = str("ANALYTICS_TOKEN", "")
+	c.SessionTTL = c.duration("SESSION_TTL_HOURS", 24, 1, time.Hour)
+
+	defaultCSP, defaultReadyDelay := "report-only", int64(5)
+	if c.IsProduction() {
+		defaultCSP = "enforce"
+	}
+	if c.IsLocal() {
+		defaultReadyDelay = 0
+	}
+	c.HTTP = HTTP{
+		TrustProxyHeaders:  c.boolean("TRUST_PROXY_HEADERS", false),
+		CORSAllowedOrigins: str("CORS_ALLOWED_ORIGINS", ""),
+		MaxBodyBytes:       c.integer("HTTP_MAX_BODY_BYTES", 1<<20, 1),
+		RouteTimeout:       c.seconds("HTTP_ROUTE_TIMEOUT_SECONDS", 20, 1),
+		ExportTimeout:      c.seconds("HTTP_EXPORT_TIMEOUT_SECONDS", 60, 1),
+		CSPMode:            c.cspMode(defaultCSP),
+		HSTSMaxAge:         c.seconds("HSTS_MAX_AGE_SECONDS", 365*24*60*60, 0),
+		ShutdownReadyDelay: c.seconds("SHUTDOWN_READY_DELAY_SECONDS", defaultReadyDelay, 0),
+		ShutdownTimeout:    c.seconds("SHUTDOWN_TIMEOUT_SECONDS", 25, 0),
+	}
+
+	c.API = API{
+		BaseURL:      str("API_BASE_URL", "http://localhost:3000/api/v1"),
+		AuthToken:    str("API_AUTH_TOKEN", ""),
+		ClientID:     str("API_CLIENT_ID", ""),
+		ClientSecret: str("API_CLIENT_SECRET", ""),
+		TokenScope:   str("API_TOKEN_SCOPE", "assets.read"),
+	}
+	c.API.AuthURL = str("API_AUTH_URL", deriveTokenURL(c.API.BaseURL))
+
+	c.Mock.Enabled = c.boolean("MOCK_ENABLED", false)
+	c.Mock.AuthEnabled = c.boolean("MOCK_AUTH_ENABLED", c.Mock.Enabled)
+
+	c.Contact = Contact{
+		Email:        str("CONTACT_EMAIL", ""),
+		EnquiryEmail: str("PROPERTY_ENQUIRY_EMAIL", ""),
+		PhoneRent:    str("CONTACT_PHONE_RENT", ""),
+		PhoneSales:   str("CONTACT_PHONE_SALES", ""),
+	}
+	if c.Contact.EnquiryEmail == "" {
+		if old := str("PROPERY_ENQUIRY_EMAIL", ""); old != "" {
+			log.Printf("config: PROPERY_ENQUIRY_EMAIL is deprecated; rename it to PROPERTY_ENQUIRY_EMAIL")
+			c.Contact.EnquiryEmail = old
+		}
+	}
+
+	c.Map = Map{
+		MapboxToken: str("MAPBOX_PUBLIC_TOKEN", ""),
+		MapboxStyle: str("MAPBOX_STYLE_URL", "mapbox://styles/mapbox/streets-v12"),
+		DefaultLat:  c.float("MAP_DEFAULT_LAT", 23.810332),
+		DefaultLng:  c.float("MAP_DEFAULT_LNG", 90.412521),
+		DefaultZoom: c.float("MAP_DEFAULT_ZOOM", 11.2),
+	}
+
+	c.Mail = Mail{
+		Provider:    c.choice("MAIL_PROVIDER", "maildir", "smtp"),
+		From:        str("MAIL_FROM", str("SMTP_FROM", "DhakaHome <info@dhakahome.com>")),
+		MaildirPath: str("MAIL_MAILDIR_PATH", filepath.Join("tmp", "mail")),
+		RedirectTo:  list("MAIL_REDIRECT_TO"),
+		StaffTo:     list("MAIL_STAFF_TO"),
+		SMTP: SMTP{
+			Host:     str("SMTP_HOST", ""),
+			Port:     str("SMTP_PORT", "587"),
+			Username: str("SMTP_USERNAME", ""),
+			Password: os.Getenv("SMTP_PASSWORD"), // not trimmed: spaces may be part of it
+		},
+	}
+	c.SMS = SMS{
+		Provider:   c.choice("SMS_PROVIDER", "console", "file", "http"),
+		OutboxPath: str("SMS_OUTBOX_PATH", filepath.Join("tmp", "sms-outbox.log")),
+		GatewayURL: str("SMS_GATEWAY_URL", ""),
+		APIKey:     str("SMS_API_KEY", ""),
+		SenderID:   str("SMS_SENDER_ID", ""),
+	}
+	c.Notify = Notify{
+		Provider:   c.choice("NOTIFY_PROVIDER", "outbox", "live"),
+		OutboxPath: str("NOTIFY_OUTBOX_PATH", filepath.Join("tmp", "notify-outbox.log")),
+	}
+	c.Captcha = Captcha{
+		Provider:  c.choice("CAPTCHA_PROVIDER", "", "turnstile", "hcaptcha", "recaptcha", "fake"),
+		SiteKey:   str("CAPTCHA_SITE_KEY", ""),
+		SecretKey: str("CAPTCHA_SECRET_KEY", ""),
+		MinScore:  c.float("CAPTCHA_MIN_SCORE", 0),
+	}
+
+	c.OTP = OTP{
+		CodeLength:     int(c.integerBetween("OTP_CODE_LENGTH", 6, 4, 10)),
+		TTL:            c.seconds("OTP_TTL_SECONDS", 300, 1),
+		MaxAttempts:    int(c.integer("OTP_MAX_ATTEMPTS", 5, 1)),
+		ResendInterval: c.seconds("OTP_RESEND_SECONDS", 60, 1),
+		MaxSendsPerDay: int(c.integer("OTP_MAX_SENDS_PER_DAY", 10, 1)),
+	}
+	c.Leads = Leads{
+		DedupeWindow:      c.duration("LEAD_DEDUPE_WINDOW_MINUTES", 30, 0, time.Minute),
+		MinFillTime:       c.seconds("LEAD_MIN_FILL_SECONDS", 3, 0),
+		SpamThreshold:     int(c.integer("LEAD_SPAM_THRESHOLD", 50, 1)),
+		OutboxMaxAttempts: int(c.integer("LEAD_OUTBOX_MAX_ATTEMPTS", 8, 1)),
+		OutboxBackoff:     c.seconds("LEAD_OUTBOX_BACKOFF_SECONDS", 30, 1),
+		OutboxPoll:        c.seconds("LEAD_OUTBOX_POLL_SECONDS", 5, 1),
+	}
+	c.RateLimits = RateLimits{
+		LeadPerIP:       c.rateLimit("RATE_LIMIT_LEAD_PER_IP", "10/10m"),
+		LeadPerContact:  c.rateLimit("RATE_LIMIT_LEAD_PER_CONTACT", "5/1h"),
+		LoginPerIP:      c.rateLimit("RATE_LIMIT_LOGIN_PER_IP", "20/15m"),
+		LoginPerAccount: c.rateLimit("RATE_LIMIT_LOGIN_PER_ACCOUNT", "5/15m"),
+		OTPPerIP:        c.rateLimit("RATE_LIMIT_OTP_PER_IP", "10/1h"),
+	}
+	c.Shortlists = Shortlists{
+		GuestMax: int(c.integer("GUEST_SHORTLIST_MAX", 20, 1)),
+		ShareTTL: c.duration("SHORTLIST_SHARE_TTL_DAYS", 14, 1, 24*time.Hour),
+	}
+	c.Alerts = Alerts{
+		SavedSearchInterval:   c.duration("SAVED_SEARCH_INTERVAL_MINUTES", 60, 1, time.Minute),
+		PropertyWatchInterval: c.duration("PROPERTY_WATCH_INTERVAL_MINUTES", 30, 1, time.Minute),
+	}
+
+	data := func(name string) string { return filepath.Join("data", name) }
+	c.Stores = Stores{
+		LeadOutbox:          jsonstore.Path(os.Getenv("LEAD_OUTBOX_PATH"), data("lead-outbox.json")),
+		LeadQuarantine:      str("LEAD_QUARANTINE_PATH", data("lead-quarantine.jsonl")),
+		LeadAttribution:     jsonstore.Path(os.Getenv("LEAD_ATTRIBUTION_PATH"), data("lead-attribution.jsonl")),
+		LeadRouting:         str("LEAD_ROUTING_PATH", data("lead-routing.json")),
+		SavedSearches:       jsonstore.Path(os.Getenv("SAVED_SEARCH_PATH"), data("saved-searches.json")),
+		PropertyWatches:     jsonstore.Path(os.Getenv("PROPERTY_WATCH_PATH"), data("property-watches.json")),
+		ShortlistMeta:       jsonstore.Path(os.Getenv("SHORTLIST_META_PATH"), data("shortlist-meta.json")),
+		ShortlistShares:     jsonstore.Path(os.Getenv("SHORTLIST_SHARE_PATH"), data("shortlist-shares.json")),
+		ViewingBookings:     jsonstore.Path(os.Getenv("VIEWING_BOOKINGS_PATH"), data("viewings.json")),
+		ViewingAvailability: str("VIEWING_AVAILABILITY_PATH", data("viewing-availability.json")),
+	}
+	return c
+}
+
+// validate checks the values the environment needs. Local only needs well-formed values;
+// staging and UAT also need real credentials; production is stricter still.
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if c.Addr == "" {
+		add("ADDR is empty")
+	}
+	if !absoluteURL(c.API.BaseURL) {
+		add("API_BASE_URL %q is not an absolute http(s) URL", c.API.BaseURL)
+	}
+	if c.PublicSiteURL != "" && !absoluteURL(c.PublicSiteURL) {
+		add("PUBLIC_SITE_URL %q is not an absolute http(s) URL", c.PublicSiteURL)
+	}
+	if !absoluteURL(c.GetStartedURL) && !strings.HasPrefix(c.GetStartedURL, "/") {
+		add("GET_STARTED_URL %q must be an absolute URL or a path", c.GetStartedURL)
+	}
+	if c.Map.DefaultLat < -90 || c.Map.DefaultLat > 90 || c.Map.DefaultLng < -180 || c.Map.DefaultLng > 180 {
+		add("MAP_DEFAULT_LAT/MAP_DEFAULT_LNG %v,%v is not a valid position", c.Map.DefaultLat, c.Map.DefaultLng)
+	}
+	if c.SMS.GatewayURL != "" && !absoluteURL(c.SMS.GatewayURL) {
+		add("SMS_GATEWAY_URL %q is not an absolute http(s) URL", c.SMS.GatewayURL)
+	}
+	if c.Captcha.MinScore < 0 || c.Captcha.MinScore > 1 {
+		add("CAPTCHA_MIN_SCORE %v must be between 0 and 1", c.Captcha.MinScore)
+	}
+	if c.IsLocal() {
+		return problems
+	}
+
+	if c.API.AuthToken == "" && (c.API.ClientID == "" || c.API.ClientSecret == "") && !(c.Mock.Enabled && c.Mock.AuthEnabled) {
+		add("API_AUTH_TOKEN or API_CLIENT_ID and API_CLIENT_SECRET are required")
+	}
+	if c.CookieSecret == "" {
+		add("COOKIE_SECRET is required (signed cookies would reset on every restart)")
+	}
+	if c.PublicSiteURL == "" {
+		add("PUBLIC_SITE_URL is required (links in emails, SMS and viewing invites)")
+	}
+	if c.Mail.Provider == "smtp" && c.Mail.SMTP.Host == "" {
+		add("MAIL_PROVIDER=smtp needs SMTP_HOST")
+	}
+	if c.Notify.Provider == "live" && c.Mail.Provider != "smtp" {
+		add("NOTIFY_PROVIDER=live sends email through the mailer, so it needs MAIL_PROVIDER=smtp")
+	}
+	if c.SMS.Provider == "http" && c.SMS.GatewayURL == "" {
+		add("SMS_PROVIDER=http needs SMS_GATEWAY_URL")
+	}
+	switch c.Captcha.Provider {
+	case "", "fake":
+	default:
+		if c.Captcha.SecretKey == "" || c.Captcha.SiteKey == "" {
+			add("CAPTCHA_PROVIDER=%s needs CAPTCHA_SITE_KEY and CAPTCHA_SECRET_KEY", c.Captcha.Provider)
+		}
+	}
+	if !c.IsProduction() {
+		return problems
+	}
+
+	if c.Mock.Enabled {
+		add("MOCK_ENABLED must be off in production")
+	}
+	if isLocalhost(c.API.BaseURL) {
+		add("API_BASE_URL %q points at localhost", c.API.BaseURL)
+	}
+	if c.PublicSiteURL != "" && !strings.HasPrefix(c.PublicSiteURL, "https://") {
+		add("PUBLIC_SITE_URL %q must use https", c.PublicSiteURL)
+	}
+	if c.CookieSecret != "" && len(c.CookieSecret) < 32 {
+		add("COOKIE_SECRET must be at least 32 characters")
+	}
+	if c.HTTP.CSPMode == "off" {
+		add("CSP_MODE=off is not allowed in production")
+	}
+	if c.Captcha.Provider == "fake" {
+		add("CAPTCHA_PROVIDER=fake is not allowed in production")
+	}
+	return problems
+}
+
+// Summary lists the settings for the startup log, one per line. Secrets only show
+// whether they are set.
+func (c *Config) Summary() string {
+	var b strings.Builder
+	line := func(key string, value any) { fmt.Fprintf(&b, "\n  %-28s %v", key, value) }
+
+	fmt.Fprintf(&b, "configuration (%s, from %s):", c.Environment, orDefault(c.EnvFile, "environment only"))
+	line("ADDR", c.Addr)
+	line("PUBLIC_SITE_URL", orDefault(c.PublicSiteURL, "(request origin)"))
+	line("GET_STARTED_URL", c.GetStartedURL)
+	line("API_BASE_URL", c.API.BaseURL)
+	line("API_AUTH_URL", c.API.AuthURL)
+	line("API_CLIENT_ID", orDefault(c.API.ClientID, "(unset)"))
+	line("API_CLIENT_SECRET", redact(c.API.ClientSecret))
+	line("API_AUTH_TOKEN", redact(c.API.AuthToken))
+	line("API_TOKEN_SCOPE", c.API.TokenScope)
+	line("MOCK_ENABLED", c.Mock.Enabled)
+	line("MOCK_AUTH_ENABLED", c.Mock.AuthEnabled)
+	line("COOKIE_SECRET", redact(c.CookieSecret))
+	line("ANALYTICS_TOKEN", redact(c.AnalyticsToken))
+	line("CONTACT_EMAIL", orDefault(c.Contact.Email, "(unset)"))
+	line("PROPERTY_ENQUIRY_EMAIL", orDefault(c.Contact.EnquiryEmail, "(unset)"))
+	line("CONTACT_PHONE_RENT", orDefault(c.Contact.PhoneRent, "(unset)"))
+	line("CONTACT_PHONE_SALES", orDefault(c.Contact.PhoneSales, "(unset)"))
+	line("MAPBOX_PUBLIC_TOKEN", redact(c.Map.MapboxToken))
+	line("MAP_DEFAULT", fmt.Sprintf("%v,%v zoom %v", c.Map.DefaultLat, c.Map.DefaultLng, c.Map.DefaultZoom))
+	line("TRUST_PROXY_HEADERS", c.HTTP.TrustProxyHeaders)
+	line("CORS_ALLOWED_ORIGINS", orDefault(c.HTTP.CORSAllowedOrigins, "(default)"))
+	line("CSP_MODE", c.HTTP.CSPMode)
+	line("HSTS_MAX_AGE", c.HTTP.HSTSMaxAge)
+	line("HTTP_MAX_BODY_BYTES", c.HTTP.MaxBodyBytes)
+	line("HTTP timeouts", fmt.Sprintf("route %s, export %s", c.HTTP.RouteTimeout, c.HTTP.ExportTimeout))
+	line("SHUTDOWN", fmt.Sprintf("ready delay %s, timeout %s", c.HTTP.ShutdownReadyDelay, c.HTTP.ShutdownTimeout))
+	line("MAIL_PROVIDER", c.Mail.Provider)
+	line("MAIL_REDIRECT_TO", orDefault(strings.Join(c.Mail.RedirectTo, ", "), "(unset)"))
+	line("SMTP_HOST", orDefault(c.Mail.SMTP.Host, "(unset)"))
+	line("SMTP_PASSWORD", redact(c.Mail.SMTP.Password))
+	line("SMS_PROVIDER", c.SMS.Provider)
+	line("SMS_GATEWAY_URL", orDefault(c.SMS.GatewayURL, "(unset)"))
+	line("SMS_API_KEY", redact(c.SMS.APIKey))
+	line("NOTIFY_PROVIDER", c.Notify.Provider)
+	line("CAPTCHA_PROVIDER", orDefault(c.Captcha.Provider, "(off)"))
+	line("CAPTCHA_SECRET_KEY", redact(c.Captcha.SecretKey))
+	line("SESSION_TTL", c.SessionTTL)
+	line("LEAD_OUTBOX_PATH", orDefault(c.Stores.LeadOutbox, "(memory)"))
+	return b.String()
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return "(unset)"
+	}
+	return "(set, redacted)"
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func str(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
+
+// list reads a comma-separated value, dropping blanks.
+func list(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (c *Config) boolean(key string, def bool) bool {
+	switch v := strings.ToLower(str(key, "")); v {
+	case "":
+		return def
+	case "true", "1", "yes", "on":
+		return true
+	case "false", "0", "no", "off":
+		return false
+	default:
+		c.problems = append(c.problems, fmt.Sprintf("%s %q is not true or false", key, v))
+		return def
+	}
+}
+
+func (c *Config) integer(key string, def, min int64) int64 {
+	v := str(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < min {
+		c.problems = append(c.problems, fmt.Sprintf("%s %q must be a whole number of at least %d", key, v, min))
+		return def
+	}
+	return n
+}
+
+// integerBetween is integer with an upper bound as well.
+func (c *Config) integerBetween(key string, def, min, max int64) int64 {
+	n := c.integer(key, def, min)
+	if n > max {
+		c.problems = append(c.problems, fmt.Sprintf("%s %q must be a whole number from %d to %d", key, str(key, ""), min, max))
+		return def
+	}
+	return n
+}
+
+func (c *Config) seconds(key string, def, min int64) time.Duration {
+	return c.duration(key, def, min, time.Second)
+}
+
+// duration reads a whole number of units, e.g. SESSION_TTL_HOURS with unit time.Hour.
+func (c *Config) duration(key string, def, min int64, unit time.Duration) time.Duration {
+	return time.Duration(c.integer(key, def, min)) * unit
+}
+
+// choice reads a lower-cased value that must be def or one of allowed.
+func (c *Config) choice(key, def string, allowed ...string) string {
+	v := strings.ToLower(str(key, def))
+	if v == def || slices.Contains(allowed, v) {
+		return v
+	}
+	c.problems = append(c.problems, fmt.Sprintf("%s %q must be one of %s", key, v, strings.Join(allowed, ", ")))
+	return def
+}
+
+// rateLimit reads "N/duration" (e.g. "5/10m"); "off" disables the rule.
+func (c *Config) rateLimit(key, def string) RateLimit {
+	v := str(key, def)
+	if strings.EqualFold(v, "off") {
+		return RateLimit{}
+	}
+	if l, ok := parseRateLimit(v); ok {
+		return l
+	}
+	c.problems = append(c.problems, fmt.Sprintf("%s %q must be N/duration, e.g. %q, or off", key, v, def))
+	l, _ := parseRateLimit(def)
+	return l
+}
+
+func parseRateLimit(v string) (RateLimit, bool) {
+	count, window, ok := strings.Cut(v, "/")
+	if !ok {
+		return RateLimit{}, false
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return RateLimit{}, false
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d <= 0 {
+		return RateLimit{}, false
+	}
+	return RateLimit{Limit: n, Window: d}, true
+}
+
+func (c *Config) cspMode(def string) string {
+	switch v := strings.ToLower(str("CSP_MODE", def)); v {
+	case "enforce", "report-only", "off":
+		return v
+	default:
+		c.problems = append(c.problems, fmt.Sprintf("CSP_MODE %q must be enforce, report-only or off", v))
+		return def
+	}
+}
+
+func (c *Config) float(key string, def float64) float64 {
+	v := str(key, "")
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		c.problems = append(c.problems, fmt.Sprintf("%s %q is not a number", key, v))
+		return def
+	}
+	return f
+}
+
+func absoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func isLocalhost(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1", "0.0.0.0":
+		return true
+	}
+	return false
+}
+
+// deriveTokenURL is the OAuth token endpoint on the API host, used when API_AUTH_URL
+// is unset.
+func deriveTokenURL(base string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "http://localhost:3000/oauth/token"
+	}
+	u.Path = "/oauth/token"
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,144 @@
+// Package csrf guards form posts with a signed double-submit token. The browser
+// holds a signed HttpOnly cookie; pages echo the raw token in a hidden field or an
+// X-CSRF-Token header, and unsafe requests must present both.
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+)
+
+const (
+	CookieName = "dh_csrf"
+	HeaderName = "X-CSRF-Token"
+	FieldName  = "csrf_token"
+
+	cookieMaxAge = 7 * 24 * time.Hour
+)
+
+type ctxKey struct{}
+
+// Protect issues a token to every visitor and rejects POST, PUT, PATCH and DELETE
+// requests whose token is missing or does not match the cookie. Requests carrying a
+// bearer token are exempt, since browsers never attach one on their own, as are the
+// exempt paths, for endpoints browsers post to without a page (CSP reports).
+func Protect(exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return handler(next, exempt)
+	}
+}
+
+func handler(next http.Handler, exempt []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := session.GetSigned(r, CookieName)
+		if (!ok || token == "") && safeMethod(r.Method) && !staticPath(r.URL.Path) {
+			token = newToken()
+			session.SetSigned(w, r, CookieName, token, cookieMaxAge)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, token))
+
+		if safeMethod(r.Method) || bearer(r) || contains(exempt, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok || token == "" || !hmac.Equal([]byte(submitted(r)), []byte(token)) {
+			log.Printf("csrf: rejected %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			reject(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Token returns the request's token for pages to embed.
+func Token(r *http.Request) string {
+	token, _ := r.Context().Value(ctxKey{}).(string)
+	return token
+}
+
+// Funcs are template helpers bound to r: csrfToken for meta tags and hx-headers, and
+// csrfField for a hidden input inside <form method="post">.
+func Funcs(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string { return Token(r) },
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + FieldName + `" value="` + template.HTMLEscapeString(Token(r)) + `" />`)
+		},
+	}
+}
+
+// submitted reads the header first so JSON bodies are never parsed here, then the
+// form field for plain HTML forms.
+func submitted(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get(HeaderName)); v != "" {
+		return v
+	}
+	ct := strings.ToLower(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(ct, "application/x-www-form-urlencoded") || strings.HasPrefix(ct, "multipart/form-data") {
+		return strings.TrimSpace(r.FormValue(FieldName))
+	}
+	return ""
+}
+
+func safeMethod(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func staticPath(p string) bool {
+	for _, prefix := range []string{"/assets/", "/favicon", "/apple-touch-icon", "/robots.txt", "/healthz", "/readyz"} {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func bearer(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	return ok && strings.EqualFold(scheme, "bearer") && strings.TrimSpace(token) != ""
+}
+
+func reject(w http.ResponseWriter, r *http.Request) {
+	const msg = "Your session has expired. Please refresh the page and try again."
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	ct := strings.ToLower(r.Header.Get("Content-Type"))
+	if strings.Contains(accept, "application/json") || strings.Contains(ct, "application/json") ||
+		r.Header.Get("HX-Request") != "" || r.Header.Get("X-Requested-With") != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+		return
+	}
+	http.Error(w, msg, http.StatusForbidden)
+}
+
+func newToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
 )
 
 type loginPayload struct {
@@ -59,15 +60,38 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour).UTC()
+	expiresAt := startUserSession(w, r, auth)
+	merged := mergeGuestShortlist(w, r, auth.Token)
 
 	writeAuthJSON(w, http.StatusOK, map[string]any{
-		"token":     auth.Token,
-		"user":      auth.User,
-		"expiresAt": expiresAt.Format(time.RFC3339),
+		"token":           auth.Token,
+		"user":            auth.User,
+		"expiresAt":       expiresAt.Format(time.RFC3339),
+		"shortlistMerged": merged,
 	})
 }
 
+// Logout clears the server-side session. The browser drops its stored token separately.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	session.Destroy(w, r)
+	writeAuthJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// startUserSession records the Nestlo login in a cookie session and returns its expiry.
+func startUserSession(w http.ResponseWriter, r *http.Request, auth api.LoginResponse) time.Time {
+	sess := session.Start(w, r, session.Session{
+		UserID:    auth.User.ID,
+		Name:      auth.User.Name,
+		Email:     auth.User.Email,
+		Phone:     auth.User.PhoneNumber,
+		Token:     auth.Token,
+		ExpiresAt: time.Now().Add(session.TTL()),
+
+		RecentlyViewed: takeGuestRecentlyViewed(w, r),
+	})
+	return sess.ExpiresAt.UTC()
+}
+
 func parseLoginPayload(r *http.Request) (loginPayload, error) {
 	ct := strings.ToLower(r.Header.Get("Content-Type"))
 	if strings.Contains(ct, "application/json") {
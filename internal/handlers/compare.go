@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+)
+
+const compareMax = 4
+
+// compareCell is one value in the comparison table.
+type compareCell struct {
+	Value string
+	Best  bool
+}
+
+// compareColumn is one property in the table with its "remove" link.
+type compareColumn struct {
+	P         api.Property
+	RemoveURL string
+}
+
+// compareRow is one attribute compared across properties.
+type compareRow struct {
+	Label string
+	Cells []compareCell
+}
+
+// ComparePage renders /compare?ids=a,b,c with up to four properties side by side.
+func ComparePage(w http.ResponseWriter, r *http.Request) {
+	ids := compareIDs(r)
+	props := fetchProperties(ids)
+	annotateShortlisted(r, props)
+
+	kept := propertyIDs(props)
+	columns := make([]compareColumn, 0, len(props))
+	for _, p := range props {
+		columns = append(columns, compareColumn{P: p, RemoveURL: compareWithout(kept, p.ID)})
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	render(w, r, "pages/compare.html", "compare.html", map[string]any{
+		"ActivePage": "properties",
+		"Columns":    columns,
+		"Rows":       buildCompareRows(props),
+		"CompareIDs": strings.Join(kept, ","),
+		"CompareMax": compareMax,
+	})
+}
+
+// compareIDs reads ids=a,b,c (or repeated ids params), de-duplicated and capped.
+func compareIDs(r *http.Request) []string {
+	raw := make([]string, 0)
+	for _, v := range r.URL.Query()["ids"] {
+		raw = append(raw, strings.Split(v, ",")...)
+	}
+	return cleanAssetIDs(raw, compareMax)
+}
+
+// fetchProperties loads properties concurrently, keeping the requested order
+// and dropping any that no longer resolve.
+func fetchProperties(ids []string) []api.Property {
+	results := make([]*api.Property, len(ids))
+	client := api.New()
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			prop, err := client.GetProperty(id)
+			if err != nil {
+				log.Printf("fetch properties: skipping %s: %v", id, err)
+				return
+			}
+			results[i] = &prop
+		}(i, id)
+	}
+	wg.Wait()
+
+	props := make([]api.Property, 0, len(ids))
+	for _, p := range results {
+		if p != nil {
+			props = append(props, *p)
+		}
+	}
+	return props
+}
+
+func buildCompareRows(props []api.Property) []compareRow {
+	if len(props) == 0 {
+		return nil
+	}
+
+	perSqft := func(p api.Property) float64 {
+		if p.Area <= 0 || p.Price <= 0 {
+			return 0
+		}
+		return p.Price / float64(p.Area)
+	}
+	money := func(v float64) string {
+		if v <= 0 {
+			return "-"
+		}
+		return "৳ " + formatPrice(v)
+	}
+	count := func(v int) string {
+		if v <= 0 {
+			return "-"
+		}
+		return strconv.Itoa(v)
+	}
+
+	rows := []compareRow{
+		numericRow("Price", props, func(p api.Property) float64 { return p.Price }, func(p api.Property) string { return money(p.Price) }, false),
+		numericRow("Price per sqft", props, perSqft, func(p api.Property) string { return money(perSqft(p)) }, false),
+		numericRow("Area", props, func(p api.Property) float64 { return float64(p.Area) }, func(p api.Property) string {
+			if p.Area <= 0 {
+				return "-"
+			}
+			return formatPrice(float64(p.Area)) + " sqft"
+		}, true),
+		numericRow("Bedrooms", props, func(p api.Property) float64 { return float64(p.Bedrooms) }, func(p api.Property) string { return count(p.Bedrooms) }, true),
+		numericRow("Bathrooms", props, func(p api.Property) float64 { return float64(p.Bathrooms) }, func(p api.Property) string { return count(p.Bathrooms) }, true),
+		numericRow("Parking", props, func(p api.Property) float64 { return float64(p.Parking) }, func(p api.Property) string { return count(p.Parking) }, true),
+		numericRow("Amenities", props, func(p api.Property) float64 { return float64(len(p.Amenities)) }, func(p api.Property) string {
+			if len(p.Amenities) == 0 {
+				return "-"
+			}
+			return strings.Join(p.Amenities, ", ")
+		}, true),
+		numericRow("Build year", props, func(p api.Property) float64 { return float64(p.BuildYear) }, func(p api.Property) string { return count(p.BuildYear) }, true),
+	}
+
+	listing := compareRow{Label: "Listing type"}
+	for _, p := range props {
+		value := "-"
+		switch normalizeListingTypeValue(p.ListingType) {
+		case "listed_rental":
+			value = "For rent"
+		case "listed_sale":
+			value = "For sale"
+		default:
+			if p.ListingType != "" {
+				value = p.ListingType
+			}
+		}
+		listing.Cells = append(listing.Cells, compareCell{Value: value})
+	}
+	return append(rows, listing)
+}
+
+// numericRow builds a row and marks the best value: the highest when higherBetter, else
+// the lowest. Missing (zero) values never win, and nothing is highlighted when all
+// compared values are equal.
+func numericRow(label string, props []api.Property, value func(api.Property) float64, format func(api.Property) string, higherBetter bool) compareRow {
+	row := compareRow{Label: label, Cells: make([]compareCell, len(props))}
+	best := 0.0
+	distinct := map[float64]struct{}{}
+	for i, p := range props {
+		row.Cells[i] = compareCell{Value: format(p)}
+		v := value(p)
+		if v <= 0 {
+			continue
+		}
+		distinct[v] = struct{}{}
+		if best == 0 || (higherBetter && v > best) || (!higherBetter && v < best) {
+			best = v
+		}
+	}
+	if len(props) < 2 || len(distinct) < 2 {
+		return row
+	}
+	for i, p := range props {
+		if value(p) == best {
+			row.Cells[i].Best = true
+		}
+	}
+	return row
+}
+
+func propertyIDs(props []api.Property) []string {
+	ids := make([]string, 0, len(props))
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// compareWithout returns the /compare link with one property removed.
+func compareWithout(ids []string, remove string) string {
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != remove {
+			kept = append(kept, url.QueryEscape(id))
+		}
+	}
+	if len(kept) == 0 {
+		return "/compare"
+	}
+	return "/compare?ids=" + strings.Join(kept, ",")
+}
@@ -1,38 +1,136 @@
 package handlers
 
 import (
-	"os"
+	"log"
 	"strings"
+	"sync"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/routing"
 )
 
-// defaultContactEmail picks CONTACT_EMAIL first, falls back to PROPERY_ENQUIRY_EMAIL or a sane default.
-func defaultContactEmail() string {
-	if email := strings.TrimSpace(os.Getenv("CONTACT_EMAIL")); email != "" {
-		return email
+var (
+	routerOnce sync.Once
+	router     *routing.Router
+)
+
+func leadRouter() *routing.Router {
+	routerOnce.Do(func() {
+		router = routing.NewRouterFromEnv()
+	})
+	return router
+}
+
+// propertySubject describes a listing for the routing rules.
+func propertySubject(p api.Property) routing.Subject {
+	return routing.Subject{
+		PropertyID:   p.ID,
+		ListingType:  p.ListingType,
+		Neighborhood: p.Neighborhood,
+		City:         p.City,
+		PropertyType: p.Type,
+		Price:        p.Price,
+		Address:      p.Address,
+	}
+}
+
+// propertyContact resolves the email and phone shown for a listing. The agent or team
+// picked by the routing rules wins. Without a matching rule the listing-type desk phone
+// is shown over the listing's own number (which may be the owner's), and the listing's
+// email over the enquiry inbox.
+func propertyContact(p api.Property) (email, phone string) {
+	routed, _ := leadRouter().Contact(propertySubject(p))
+
+	email = strings.TrimSpace(firstNonEmpty(routed.Email, p.ContactEmail, config.Get().Contact.EnquiryEmail, "enquiry@dhakahome.com"))
+	phone = firstNonEmpty(
+		displayPhone(routed.Phone),
+		defaultContactPhone(p.ListingType),
+		displayPhone(p.ContactPhone),
+		displayPhone("01877-721-579"),
+	)
+	return email, phone
+}
+
+// propertyWhatsApp is the click-to-chat number for a listing: the routed agent's
+// WhatsApp number when they have a separate one, otherwise the phone shown on the page.
+func propertyWhatsApp(p api.Property) string {
+	routed, _ := leadRouter().Contact(propertySubject(p))
+	if number := displayPhone(routed.WhatsApp); number != "" {
+		return number
 	}
-	if email := strings.TrimSpace(os.Getenv("PROPERY_ENQUIRY_EMAIL")); email != "" {
-		return email
+	_, phone := propertyContact(p)
+	return phone
+}
+
+// leadProperty looks up the listing a lead is about. It returns the zero Property when
+// there is none or it cannot be found.
+func leadProperty(propertyID string) api.Property {
+	id := strings.TrimSpace(propertyID)
+	if id == "" {
+		return api.Property{}
 	}
-	return "info@dhakahome.com"
+	p, found, err := api.New().LookupProperty(id)
+	if err != nil {
+		log.Printf("lead: property %s: %v", id, err)
+	}
+	if err != nil || !found {
+		return api.Property{}
+	}
+	return p
+}
+
+// leadSubject routes on the enquired listing when it was found, otherwise on the
+// listing type the form sent.
+func leadSubject(p api.Property, listingType string) routing.Subject {
+	if p.ID != "" {
+		return propertySubject(p)
+	}
+	return routing.Subject{ListingType: listingType}
+}
+
+// routeLead assigns a lead to an agent or team: the internal alert goes to their inbox
+// and the Nestlo lead carries the assignee. Leads no rule matches keep their defaults.
+func routeLead(s routing.Subject, lead *api.LeadReq, nestlo *api.NestloLeadPayload) routing.Assignment {
+	a, ok := leadRouter().Assign(s)
+	if !ok {
+		return a
+	}
+	if lead != nil && a.Email != "" {
+		lead.ContactEmail = a.Email
+	}
+	if nestlo != nil {
+		nestlo.AssignedAgentID = a.NestloID
+		nestlo.Notes = strings.TrimSpace(nestlo.Notes + "\n\nAssigned to: " + a.Label() + " (rule " + a.Rule + ")")
+	}
+	return a
+}
+
+// defaultContactEmail picks CONTACT_EMAIL first, falls back to PROPERTY_ENQUIRY_EMAIL or a sane default.
+func defaultContactEmail() string {
+	contact := config.Get().Contact
+	return firstNonEmpty(contact.Email, contact.EnquiryEmail, "info@dhakahome.com")
 }
 
 // defaultContactPhone selects a phone number based on listing type and env fallbacks.
 func defaultContactPhone(listingType string) string {
-	switch normalizeListingTypeValue(listingType) {
-	case "listed_rental":
-		if phone := envContactPhone("CONTACT_PHONE_RENT"); phone != "" {
+	contact := config.Get().Contact
+	switch routing.ListingType(listingType) {
+	case "rent":
+		if phone := displayPhone(contact.PhoneRent); phone != "" {
 			return phone
 		}
-	case "listed_sale":
-		if phone := envContactPhone("CONTACT_PHONE_SALES"); phone != "" {
+	case "sale":
+		if phone := displayPhone(contact.PhoneSales); phone != "" {
 			return phone
 		}
 	}
-	return envContactPhone("CONTACT_PHONE_RENT")
+	return displayPhone(contact.PhoneRent)
 }
 
-func envContactPhone(key string) string {
-	raw := strings.TrimSpace(os.Getenv(key))
+// displayPhone normalises Bangladeshi numbers and leaves anything else as written.
+func displayPhone(raw string) string {
+	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return ""
 	}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ServerError is the branded 500 response used by the panic recoverer. API and htmx
+// callers get JSON; browsers get the error page with the request ID to quote.
+func ServerError(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	w.Header().Set("Cache-Control", "no-store")
+	if wantsJSON(r) {
+		writeLeadJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":     "Something went wrong. Please try again.",
+			"requestId": requestID,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	render(w, r, "pages/error.html", "error.html", map[string]any{
+		"Status":       http.StatusInternalServerError,
+		"RequestID":    requestID,
+		"ContactPhone": firstNonEmpty(defaultContactPhone(""), displayPhone("01877-721-579")),
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+)
+
+const (
+	guestShortlistCookie = "dh_guest_shortlist"
+	guestShortlistMaxAge = 30 * 24 * time.Hour
+)
+
+// guestShortlistMax caps how many properties a visitor can save before logging in.
+func guestShortlistMax() int {
+	return config.Get().Shortlists.GuestMax
+}
+
+// readGuestShortlist returns the asset IDs in the signed guest cookie, newest first.
+func readGuestShortlist(r *http.Request) []string {
+	raw, ok := session.GetSigned(r, guestShortlistCookie)
+	if !ok || raw == "" {
+		return nil
+	}
+	return cleanAssetIDs(strings.Split(raw, ","), guestShortlistMax())
+}
+
+func writeGuestShortlist(w http.ResponseWriter, r *http.Request, ids []string) {
+	ids = cleanAssetIDs(ids, guestShortlistMax())
+	if len(ids) == 0 {
+		session.Clear(w, r, guestShortlistCookie)
+		return
+	}
+	session.SetSigned(w, r, guestShortlistCookie, strings.Join(ids, ","), guestShortlistMaxAge)
+}
+
+// addGuestShortlist prepends assetID to the guest list. It reports false when the cap is reached.
+func addGuestShortlist(w http.ResponseWriter, r *http.Request, assetID string) bool {
+	ids := readGuestShortlist(r)
+	for _, id := range ids {
+		if id == assetID {
+			return true
+		}
+	}
+	if len(ids) >= guestShortlistMax() {
+		return false
+	}
+	writeGuestShortlist(w, r, append([]string{assetID}, ids...))
+	return true
+}
+
+func removeGuestShortlist(w http.ResponseWriter, r *http.Request, assetID string) {
+	ids := readGuestShortlist(r)
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != assetID {
+			kept = append(kept, id)
+		}
+	}
+	writeGuestShortlist(w, r, kept)
+}
+
+// guestShortlistPage loads one page of the guest list, skipping listings that no longer resolve.
+func guestShortlistPage(r *http.Request, page, limit int) api.PropertyList {
+	ids := readGuestShortlist(r)
+	total := len(ids)
+	pages := int(math.Ceil(float64(total) / float64(limit)))
+	if pages == 0 {
+		pages = 1
+	}
+	if page > pages {
+		page = pages
+	}
+	start := (page - 1) * limit
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	client := api.New()
+	items := make([]api.Property, 0, end-start)
+	for _, id := range ids[start:end] {
+		prop, err := client.GetProperty(id)
+		if err != nil {
+			log.Printf("guest shortlist: skipping %s: %v", id, err)
+			continue
+		}
+		prop.IsShortlisted = true
+		items = append(items, prop)
+	}
+
+	return api.PropertyList{
+		Items: items,
+		Page:  page,
+		Pages: pages,
+		Total: total,
+	}
+}
+
+// mergeGuestShortlist copies the visitor's cookie shortlist into their Nestlo default list
+// and clears the cookie. Failures are logged and the remaining items are kept for a retry.
+func mergeGuestShortlist(w http.ResponseWriter, r *http.Request, userToken string) int {
+	ids := readGuestShortlist(r)
+	if len(ids) == 0 || strings.TrimSpace(userToken) == "" {
+		return 0
+	}
+
+	client := api.New()
+	merged := 0
+	failed := make([]string, 0)
+	// Oldest first so the newest guest pick ends up on top of the user's list.
+	for i := len(ids) - 1; i >= 0; i-- {
+		if _, err := client.AddToShortlist(ids[i], userToken); err != nil {
+			log.Printf("guest shortlist merge: %s: %v", ids[i], err)
+			failed = append([]string{ids[i]}, failed...)
+			continue
+		}
+		merged++
+	}
+
+	writeGuestShortlist(w, r, failed)
+	if merged > 0 {
+		log.Printf("guest shortlist merge: added %d of %d items", merged, len(ids))
+	}
+	return merged
+}
+
+// cleanAssetIDs trims, de-duplicates and caps a list of asset IDs, keeping order.
+func cleanAssetIDs(ids []string, max int) []string {
+	seen := make(map[string]struct{}, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+		if max > 0 && len(out) >= max {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var ready atomic.Bool
+
+// SetReady flips what /readyz reports. main marks the server ready once it is
+// listening and not ready as soon as shutdown begins, so the load balancer stops
+// sending traffic while in-flight requests drain.
+func SetReady(v bool) {
+	ready.Store(v)
+}
+
+// Healthz is the liveness check: the process is up and serving.
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Readyz is the readiness check: 200 while accepting traffic, 503 during startup and
+// shutdown.
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if !ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
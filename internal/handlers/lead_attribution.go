@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/attribution"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+var (
+	attributionOnce sync.Once
+	attributionLog  attribution.Log
+)
+
+func leadAttribution() attribution.Log {
+	attributionOnce.Do(func() {
+		attributionLog = attribution.NewLogFromEnv()
+	})
+	return attributionLog
+}
+
+// attributeLead copies the visitor's campaign and referrer onto both lead payloads.
+// Either payload may be nil.
+func attributeLead(r *http.Request, lead *api.LeadReq, nestlo *api.NestloLeadPayload) {
+	t := attribution.FromRequest(r)
+	if t.Empty() {
+		return
+	}
+	if lead != nil {
+		lead.UTMSource = t.SourceLabel()
+		lead.UTMMedium = t.MediumLabel()
+		lead.UTMCampaign = t.Campaign
+		lead.UTMTerm = t.Term
+		lead.UTMContent = t.Content
+		lead.GCLID = t.GCLID
+		lead.FBCLID = t.FBCLID
+		lead.Referrer = t.Referrer
+		lead.LandingPage = t.LandingPage
+	}
+	if nestlo != nil {
+		nestlo.Source = t.NestloSource()
+		nestlo.Notes = strings.TrimSpace(nestlo.Notes + "\n\nAttribution: " + t.Summary())
+	}
+}
+
+// recordLeadAttribution counts a delivered lead towards its source for the analytics view.
+func recordLeadAttribution(r *http.Request, leadID, form string) {
+	rec := attribution.NewRecord(leadID, form, attribution.FromRequest(r))
+	if err := leadAttribution().Append(rec); err != nil {
+		log.Printf("lead attribution: %v", err)
+	}
+}
+
+// LeadSourcesPage reports lead counts by source, medium and campaign for the last ?days=
+// (default 30). It needs ANALYTICS_TOKEN as ?token= or a bearer token; without one it is
+// only served in local development.
+func LeadSourcesPage(w http.ResponseWriter, r *http.Request) {
+	if !analyticsAllowed(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	days := 30
+	if v, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && v > 0 && v <= 365 {
+		days = v
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	records, err := leadAttribution().Since(since)
+	if err != nil {
+		log.Printf("lead attribution: %v", err)
+		http.Error(w, "could not read lead attribution", http.StatusInternalServerError)
+		return
+	}
+	rows := attribution.Summarize(records)
+	channels := attribution.SummarizeChannels(records)
+
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"days": days, "total": len(records), "sources": rows, "channels": channels})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+	render(w, r, "pages/lead-sources.html", "lead-sources.html", map[string]any{
+		"Days":     days,
+		"Total":    len(records),
+		"Rows":     rows,
+		"Channels": channels,
+		"Token":    r.URL.Query().Get("token"),
+		"Periods":  []int{7, 30, 90, 365},
+	})
+}
+
+func analyticsAllowed(r *http.Request) bool {
+	cfg := config.Get()
+	want := cfg.AnalyticsToken
+	if want == "" {
+		return cfg.Environment == "local"
+	}
+	got := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		got = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(want)) == 1
+}
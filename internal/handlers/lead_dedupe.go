@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/leadoutbox"
+	"github.com/BohoBytes/dhakahome-web/internal/mailer"
+	"github.com/BohoBytes/dhakahome-web/internal/ratelimit"
+	"github.com/BohoBytes/dhakahome-web/internal/routing"
+)
+
+var (
+	leadDedupeOnce sync.Once
+	leadDedupeSeen *ratelimit.Dedupe
+)
+
+// leadDedupe remembers recent leads for LEAD_DEDUPE_WINDOW_MINUTES (default 30, 0 disables).
+func leadDedupe() *ratelimit.Dedupe {
+	leadDedupeOnce.Do(func() {
+		leadDedupeSeen = ratelimit.NewDedupe(config.Get().Leads.DedupeWindow)
+	})
+	return leadDedupeSeen
+}
+
+// mergeRepeatLead folds a repeat enquiry into the earlier lead instead of sending
+// Nestlo another one. A new message still reaches staff as a follow-up alert so
+// nothing the enquirer wrote is lost.
+func mergeRepeatLead(r *http.Request, prev ratelimit.Submission, keys []string, in leadPayload) {
+	log.Printf("lead %s: merged repeat enquiry for property %s", prev.Reference, in.PropertyID)
+
+	msg := strings.TrimSpace(in.Message)
+	if msg == "" || msg == prev.Message {
+		return
+	}
+	prev.Message = msg
+	leadDedupe().Remember(keys, prev)
+
+	mail := newMailLead(r, leadoutbox.NewLeadID(), "lead")
+	mail.Name, mail.Email, mail.Phone, mail.Message = in.Name, in.Email, in.Phone, msg
+	mail.PropertyID = in.PropertyID
+	mail.Details = []mailer.Detail{{Label: "Follow-up to", Value: prev.Reference}}
+	queueLeadMail(mail, routing.Assignment{Email: prev.Owner}, false)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/attribution"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/leadoutbox"
+	"github.com/BohoBytes/dhakahome-web/internal/mailer"
+	"github.com/BohoBytes/dhakahome-web/internal/routing"
+)
+
+// Lead email outbox job kinds: the staff alert and the enquirer's acknowledgement.
+const (
+	leadJobAlert = "mail_lead_alert"
+	leadJobAck   = "mail_lead_ack"
+)
+
+var (
+	leadMailerOnce sync.Once
+	leadMailSender mailer.Sender
+)
+
+func leadMailer() mailer.Sender {
+	leadMailerOnce.Do(func() {
+		leadMailSender = mailer.NewFromEnv()
+	})
+	return leadMailSender
+}
+
+// sendLeadMail is the outbox handler for lead email jobs.
+func sendLeadMail(j leadoutbox.Job) error {
+	var e mailer.Email
+	if err := json.Unmarshal(j.Payload, &e); err != nil {
+		return leadoutbox.Permanent(err)
+	}
+	err := leadMailer().Send(e)
+	if errors.Is(err, mailer.ErrRecipient) {
+		return leadoutbox.Permanent(err)
+	}
+	return err
+}
+
+// leadAlertRecipients returns MAIL_STAFF_TO when set, otherwise the inbox the lead was
+// routed to, falling back to the general contact address. Recipients never come from
+// the request: a form field here would let anyone send our alert to any address.
+func leadAlertRecipients(assigned routing.Assignment) []string {
+	if staff := config.Get().Mail.StaffTo; len(staff) > 0 {
+		return staff
+	}
+	return []string{strings.TrimSpace(firstNonEmpty(assigned.Email, defaultContactEmail()))}
+}
+
+// newMailLead fills the parts of a lead email every form shares.
+func newMailLead(r *http.Request, leadID, form string) mailer.Lead {
+	l := mailer.Lead{
+		Reference: leadID,
+		Form:      form,
+		SiteURL:   siteURL(r),
+		At:        time.Now(),
+	}
+	if t := attribution.FromRequest(r); !t.Empty() {
+		l.Source = t.Summary()
+	}
+	return l
+}
+
+// queueLeadMail renders the staff alert for the assignee and, when ack is set and the
+// enquirer left an email, the acknowledgement, and queues both on the lead outbox. The
+// lead itself is already queued, so failures here are only logged.
+func queueLeadMail(l mailer.Lead, assigned routing.Assignment, ack bool) {
+	var jobs []leadoutbox.Job
+	add := func(kind string, e mailer.Email, err error) {
+		if err == nil {
+			var j leadoutbox.Job
+			if j, err = leadoutbox.NewJob(l.Reference, kind, e); err == nil {
+				jobs = append(jobs, j)
+				return
+			}
+		}
+		log.Printf("lead %s: %s email: %v", l.Reference, kind, err)
+	}
+
+	alert, err := mailer.LeadAlert(l, leadAlertRecipients(assigned))
+	add(leadJobAlert, alert, err)
+	if ack && l.Email != "" {
+		reply, err := mailer.LeadAcknowledgement(l)
+		add(leadJobAck, reply, err)
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	worker := leadOutbox()
+	if err := worker.Store.Enqueue(jobs...); err != nil {
+		log.Printf("lead %s: queue emails: %v", l.Reference, err)
+		return
+	}
+	worker.Kick()
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/leadoutbox"
+)
+
+// Outbox job kinds, one per Nestlo endpoint.
+const (
+	leadJobSubmit = "lead"        // POST /leads (agent notification)
+	leadJobNestlo = "nestlo_lead" // POST /admin/leads (CRM record)
+)
+
+var (
+	leadOutboxOnce   sync.Once
+	leadOutboxWorker *leadoutbox.Worker
+)
+
+func leadOutbox() *leadoutbox.Worker {
+	leadOutboxOnce.Do(func() {
+		leadOutboxWorker = leadoutbox.NewWorkerFromEnv(leadoutbox.NewFromEnv())
+		leadOutboxWorker.Register(leadJobSubmit, func(j leadoutbox.Job) error {
+			var in api.LeadReq
+			if err := json.Unmarshal(j.Payload, &in); err != nil {
+				return leadoutbox.Permanent(err)
+			}
+			return leadDeliveryError(api.New().SubmitLeadWithKey(in, j.ID))
+		})
+		leadOutboxWorker.Register(leadJobNestlo, func(j leadoutbox.Job) error {
+			var in api.NestloLeadPayload
+			if err := json.Unmarshal(j.Payload, &in); err != nil {
+				return leadoutbox.Permanent(err)
+			}
+			return leadDeliveryError(api.New().CreateNestloLeadWithKey(in, j.ID))
+		})
+		leadOutboxWorker.Register(viewingJobNotice, sendViewingNotice)
+		leadOutboxWorker.Register(leadJobAlert, sendLeadMail)
+		leadOutboxWorker.Register(leadJobAck, sendLeadMail)
+	})
+	return leadOutboxWorker
+}
+
+// StartLeadOutbox delivers queued leads in the background until ctx is done. The
+// returned channel closes once the worker has finished its current delivery.
+func StartLeadOutbox(ctx context.Context) <-chan struct{} {
+	return runBackground(func() { leadOutbox().Run(ctx) })
+}
+
+// DrainLeadOutbox makes one last pass over due jobs before exit, so leads accepted by
+// the final requests go out now rather than after the next deploy. Call it only after
+// the worker from StartLeadOutbox has stopped. Whatever is left stays on disk.
+func DrainLeadOutbox(ctx context.Context) {
+	leadOutbox().RunOnce(ctx)
+}
+
+// runBackground runs fn in a goroutine and returns a channel closed when it returns.
+func runBackground(fn func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	return done
+}
+
+// queueLead durably stores the deliveries for one submission and wakes the worker.
+// Either payload may be nil. It returns the lead reference shown to the visitor.
+func queueLead(lead *api.LeadReq, nestlo *api.NestloLeadPayload) (string, error) {
+	leadID := leadoutbox.NewLeadID()
+	jobs := make([]leadoutbox.Job, 0, 2)
+	if lead != nil {
+		j, err := leadoutbox.NewJob(leadID, leadJobSubmit, lead)
+		if err != nil {
+			return "", err
+		}
+		jobs = append(jobs, j)
+	}
+	if nestlo != nil {
+		j, err := leadoutbox.NewJob(leadID, leadJobNestlo, nestlo)
+		if err != nil {
+			return "", err
+		}
+		jobs = append(jobs, j)
+	}
+
+	worker := leadOutbox()
+	if err := worker.Store.Enqueue(jobs...); err != nil {
+		return "", err
+	}
+	worker.Kick()
+	return leadID, nil
+}
+
+// leadDeliveryError decides whether a failed delivery is worth retrying. 4xx responses
+// (except timeouts and rate limits) will not succeed on retry, so they are dead-lettered
+// for an operator to check at /analytics/outbox. That includes 409: Nestlo documents no
+// idempotency contract, so a conflict cannot be told apart from a rejected lead. A
+// delivery whose outcome is unknown is dead-lettered too, since Nestlo may already hold
+// the lead and an automatic retry could create it twice.
+func leadDeliveryError(err error) error {
+	if errors.Is(err, api.ErrOutcomeUnknown) {
+		return leadoutbox.Permanent(fmt.Errorf("%w (check Nestlo for this lead before requeueing)", err))
+	}
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusTooManyRequests:
+		return err
+	case apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusUnauthorized:
+		return leadoutbox.Permanent(err)
+	}
+	return err
+}
+
+// LeadOutboxPage lists dead-lettered deliveries with their payload and last error, so
+// a lead that failed permanently can be checked and requeued. It is gated like
+// LeadSourcesPage.
+func LeadOutboxPage(w http.ResponseWriter, r *http.Request) {
+	if !analyticsAllowed(r) {
+		http.NotFound(w, r)
+		return
+	}
+	dead := leadOutbox().Store.DeadLetters()
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"dead": dead})
+		return
+	}
+
+	type row struct {
+		leadoutbox.Job
+		PayloadJSON string
+	}
+	rows := make([]row, 0, len(dead))
+	for _, j := range dead {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, j.Payload, "", "  "); err != nil {
+			pretty.Write(j.Payload)
+		}
+		rows = append(rows, row{Job: j, PayloadJSON: pretty.String()})
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+	render(w, r, "pages/lead-outbox.html", "lead-outbox.html", map[string]any{
+		"Rows":     rows,
+		"Requeued": r.URL.Query().Get("requeued"),
+		"Token":    r.URL.Query().Get("token"),
+	})
+}
+
+// RequeueLeadOutboxJob moves the dead job named by the id form or query field back into
+// the queue with a fresh set of attempts and wakes the worker.
+func RequeueLeadOutboxJob(w http.ResponseWriter, r *http.Request) {
+	if !analyticsAllowed(r) {
+		http.NotFound(w, r)
+		return
+	}
+	id := strings.TrimSpace(r.FormValue("id"))
+	worker := leadOutbox()
+	switch err := worker.Store.Requeue(id, time.Now()); {
+	case errors.Is(err, leadoutbox.ErrNotFound):
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, leadoutbox.ErrNotDead):
+		http.Error(w, "job is not dead-lettered", http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("lead outbox: requeue %s: %v", id, err)
+		http.Error(w, "could not requeue job", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("lead outbox: %s requeued by operator", id)
+	worker.Kick()
+
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"requeued": id})
+		return
+	}
+	q := url.Values{"requeued": {id}}
+	if token := r.URL.Query().Get("token"); token != "" {
+		q.Set("token", token)
+	}
+	http.Redirect(w, r, "/analytics/outbox?"+q.Encode(), http.StatusSeeOther)
+}
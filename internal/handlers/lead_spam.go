@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+	"github.com/BohoBytes/dhakahome-web/internal/spam"
+)
+
+const formTokenPrefix = "form:"
+
+var (
+	spamOnce       sync.Once
+	spamVerifier   spam.Verifier
+	spamWidget     spam.Widget
+	spamQuarantine spam.Quarantine
+)
+
+func spamGuard() (spam.Verifier, spam.Widget, spam.Quarantine) {
+	spamOnce.Do(func() {
+		spamVerifier, spamWidget = spam.NewVerifierFromEnv()
+		spamQuarantine = spam.NewQuarantineFromEnv()
+	})
+	return spamVerifier, spamWidget, spamQuarantine
+}
+
+// spamGuardData feeds partials/spam-guard.html: a signed render time for the minimum
+// fill-time check and the captcha widget, if one is configured.
+func spamGuardData() map[string]any {
+	_, widget, _ := spamGuard()
+	return map[string]any{
+		"FormToken": session.Sign(formTokenPrefix + strconv.FormatInt(time.Now().Unix(), 10)),
+		"Captcha":   widget,
+	}
+}
+
+// leadMinFillTime is how long a person needs at least to fill in a lead form.
+func leadMinFillTime() time.Duration {
+	return config.Get().Leads.MinFillTime
+}
+
+// leadSpamThreshold is the score at which a lead is quarantined instead of delivered.
+func leadSpamThreshold() int {
+	return config.Get().Leads.SpamThreshold
+}
+
+// screenLead scores a submission. It returns captchaOK=false when the visitor must retry
+// the challenge; everything else only contributes to the score.
+func screenLead(r *http.Request, in leadPayload) (result spam.Result, captchaOK bool) {
+	verifier, _, _ := spamGuard()
+
+	if strings.TrimSpace(in.Website) != "" {
+		result.Add(100, "honeypot filled")
+	}
+
+	if raw, ok := session.Unsign(in.FormToken); !ok || !strings.HasPrefix(raw, formTokenPrefix) {
+		result.Add(30, "missing form token")
+	} else if started, err := strconv.ParseInt(strings.TrimPrefix(raw, formTokenPrefix), 10, 64); err != nil {
+		result.Add(30, "bad form token")
+	} else if elapsed := time.Since(time.Unix(started, 0)); elapsed < leadMinFillTime() {
+		result.Add(60, "submitted in "+elapsed.Round(100*time.Millisecond).String())
+	}
+
+	content := spam.ScoreContent(in.Name, in.Email, in.Message)
+	result.Score += content.Score
+	result.Reasons = append(result.Reasons, content.Reasons...)
+
+	if verifier == nil {
+		return result, true
+	}
+	ok, err := verifier.Verify(in.CaptchaToken, remoteIP(r))
+	switch {
+	case errors.Is(err, spam.ErrCaptchaMissing), err == nil && !ok:
+		return result, false
+	case err != nil:
+		// Fail open when the provider is unreachable, but count it against the lead.
+		log.Printf("captcha: %v", err)
+		result.Add(20, "captcha unavailable")
+	}
+	return result, true
+}
+
+// quarantineLead keeps a suspected-spam submission for review instead of delivering it.
+func quarantineLead(r *http.Request, form string, result spam.Result, payload any) {
+	_, _, q := spamGuard()
+	raw, _ := json.Marshal(payload)
+	err := q.Put(spam.Entry{
+		Form:     form,
+		RemoteIP: remoteIP(r),
+		Result:   result,
+		Payload:  raw,
+	})
+	if err != nil {
+		log.Printf("lead quarantine: %v", err)
+		return
+	}
+	log.Printf("lead quarantined from %s (score %d: %s)", remoteIP(r), result.Score, strings.Join(result.Reasons, ", "))
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
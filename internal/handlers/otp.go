@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/otp"
+	"github.com/BohoBytes/dhakahome-web/internal/sms"
+)
+
+type otpPayload struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
+
+var (
+	otpOnce   sync.Once
+	otpCodes  *otp.Store
+	smsSender sms.Sender
+)
+
+// otpDeps builds the OTP store and SMS sender on first use, after env files are loaded.
+func otpDeps() (*otp.Store, sms.Sender) {
+	otpOnce.Do(func() {
+		otpCodes = otp.NewStore(otp.ConfigFromEnv())
+		smsSender = sms.NewFromEnv()
+	})
+	return otpCodes, smsSender
+}
+
+// RequestOTP sends a one-time login code to a Bangladeshi mobile number.
+func RequestOTP(w http.ResponseWriter, r *http.Request) {
+	if !api.PhoneLoginAvailable() {
+		writePhoneLoginUnavailable(w)
+		return
+	}
+	in, err := parseOTPPayload(r)
+	if err != nil {
+		writeAuthJSON(w, http.StatusBadRequest, map[string]any{"error": "Invalid request payload."})
+		return
+	}
+
+	phone, err := normalizeBDPhone(in.Phone)
+	if err != nil {
+		writeAuthJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": map[string]string{"phone": err.Error()},
+		})
+		return
+	}
+
+	store, sender := otpDeps()
+	code, err := store.Issue(phone)
+	if err != nil {
+		var throttle *otp.ThrottleError
+		if errors.As(err, &throttle) {
+			secs := int(math.Ceil(throttle.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			writeAuthJSON(w, http.StatusTooManyRequests, map[string]any{
+				"error":      fmt.Sprintf("Please wait %d seconds before requesting another code.", secs),
+				"retryAfter": secs,
+			})
+			return
+		}
+		log.Printf("otp issue failed for %s: %v", maskPhone(phone), err)
+		writeAuthJSON(w, http.StatusInternalServerError, map[string]any{"error": "Could not create a login code."})
+		return
+	}
+
+	minutes := int(math.Ceil(store.TTL().Minutes()))
+	msg := fmt.Sprintf("Your DhakaHome login code is %s. It expires in %d minutes. Do not share it with anyone.", code, minutes)
+	if err := sender.Send(phone, msg); err != nil {
+		log.Printf("otp sms failed for %s: %v", maskPhone(phone), err)
+		writeAuthJSON(w, http.StatusBadGateway, map[string]any{"error": "We could not send the SMS right now. Please try again."})
+		return
+	}
+
+	writeAuthJSON(w, http.StatusOK, map[string]any{
+		"status":    "sent",
+		"phone":     phone,
+		"expiresIn": int(store.TTL().Seconds()),
+	})
+}
+
+// VerifyOTP checks a login code and, on success, starts a session for the phone number.
+func VerifyOTP(w http.ResponseWriter, r *http.Request) {
+	if !api.PhoneLoginAvailable() {
+		writePhoneLoginUnavailable(w)
+		return
+	}
+	in, err := parseOTPPayload(r)
+	if err != nil {
+		writeAuthJSON(w, http.StatusBadRequest, map[string]any{"error": "Invalid request payload."})
+		return
+	}
+
+	errs := make(map[string]string)
+	phone, err := normalizeBDPhone(in.Phone)
+	if err != nil {
+		errs["phone"] = err.Error()
+	}
+	code := strings.TrimSpace(in.Code)
+	if code == "" {
+		errs["code"] = "Enter the code we sent you."
+	}
+	if len(errs) > 0 {
+		writeAuthJSON(w, http.StatusBadRequest, map[string]any{"errors": errs})
+		return
+	}
+
+	store, _ := otpDeps()
+	if err := store.Verify(phone, code); err != nil {
+		status := http.StatusUnauthorized
+		msg := "That code is not correct."
+		switch {
+		case errors.Is(err, otp.ErrNoCode):
+			status = http.StatusBadRequest
+			msg = "Request a new code to continue."
+		case errors.Is(err, otp.ErrExpired):
+			msg = "That code has expired. Request a new one."
+		case errors.Is(err, otp.ErrTooManyAttempts):
+			status = http.StatusTooManyRequests
+			msg = "Too many incorrect attempts. Request a new code."
+		}
+		writeAuthJSON(w, status, map[string]any{
+			"error":        msg,
+			"attemptsLeft": store.AttemptsLeft(phone),
+		})
+		return
+	}
+
+	client := api.New()
+	auth, err := client.LoginWithPhone(phone)
+	if err != nil {
+		// One message for every failure, so the response never says whether a number has an account.
+		log.Printf("phone login error for %s: %v", maskPhone(phone), err)
+		writeAuthJSON(w, http.StatusBadGateway, map[string]any{"error": "Login failed. Please try again."})
+		return
+	}
+	if strings.TrimSpace(auth.User.PhoneNumber) == "" {
+		auth.User.PhoneNumber = phone
+	}
+
+	expiresAt := startUserSession(w, r, auth)
+	merged := mergeGuestShortlist(w, r, auth.Token)
+
+	writeAuthJSON(w, http.StatusOK, map[string]any{
+		"token":           auth.Token,
+		"user":            auth.User,
+		"expiresAt":       expiresAt.Format(time.RFC3339),
+		"shortlistMerged": merged,
+	})
+}
+
+// writePhoneLoginUnavailable answers OTP requests when no session could follow, so we
+// never pay for an SMS the user cannot log in with.
+func writePhoneLoginUnavailable(w http.ResponseWriter) {
+	writeAuthJSON(w, http.StatusServiceUnavailable, map[string]any{
+		"error": "Mobile number login is not available yet. Please log in with your email.",
+	})
+}
+
+func parseOTPPayload(r *http.Request) (otpPayload, error) {
+	ct := strings.ToLower(r.Header.Get("Content-Type"))
+	if strings.Contains(ct, "application/json") {
+		defer r.Body.Close()
+		var payload otpPayload
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+			return otpPayload{}, err
+		}
+		return payload, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return otpPayload{}, err
+	}
+
+	return otpPayload{
+		Phone: r.FormValue("phone"),
+		Code:  r.FormValue("code"),
+	}, nil
+}
+
+// maskPhone keeps logs useful without storing full numbers.
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return "****"
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
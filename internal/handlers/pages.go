@@ -6,7 +6,6 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,6 +13,9 @@ import (
 	"unicode"
 
 	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/csrf"
+	"github.com/BohoBytes/dhakahome-web/internal/mw"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -25,9 +27,18 @@ type FeaturedArea struct {
 	SearchURL    string
 }
 
+// requestFuncs are the template helpers bound to the request: the CSRF token and
+// field, the CSP nonce for inline scripts, and whether to offer phone login.
+func requestFuncs(r *http.Request) template.FuncMap {
+	funcs := csrf.Funcs(r)
+	funcs["cspNonce"] = func() string { return mw.CSPNonce(r) }
+	funcs["phoneLogin"] = api.PhoneLoginAvailable
+	return funcs
+}
+
 // render parses ONLY the base layout + the requested page (+ partials as needed),
 // so each page can define its own "content" without collisions.
-func render(w http.ResponseWriter, topLevelTemplate string, pageFile string, data any) {
+func render(w http.ResponseWriter, r *http.Request, topLevelTemplate string, pageFile string, data any) {
 	log.Printf("Rendering template: %s with page: %s", topLevelTemplate, pageFile)
 	if m, ok := data.(map[string]any); ok {
 		if _, exists := m["GetStartedURL"]; !exists {
@@ -42,7 +53,8 @@ func render(w http.ResponseWriter, topLevelTemplate string, pageFile string, dat
 		"sub":         sub,
 		"seq":         seq,
 		"dict":        dict,
-	}).ParseFiles(
+		"join":        strings.Join,
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/"+pageFile,
 		"internal/views/partials/page-header.html",
@@ -60,6 +72,9 @@ func render(w http.ResponseWriter, topLevelTemplate string, pageFile string, dat
 		"internal/views/partials/properties-by-area.html",
 		"internal/views/partials/testimonials.html",
 		"internal/views/partials/faq.html",
+		"internal/views/partials/recently-viewed.html",
+		"internal/views/partials/spam-guard.html",
+		"internal/views/partials/viewing-booking.html",
 	))
 	log.Printf("Templates parsed successfully")
 	if err := t.ExecuteTemplate(w, topLevelTemplate, data); err != nil {
@@ -78,16 +93,18 @@ func Home(w http.ResponseWriter, r *http.Request) {
 		"ShowResults":      false,
 		"ActivePage":       "home",
 		"ShortlistEnabled": true,
+		"RecentlyViewed":   loadRecentlyViewed(r, recentStripSize, ""),
 	})
 	data["GetStartedURL"] = getStartedURL()
 	data = withTopAreas(data)
-	render(w, "pages/home.html", "home.html", data)
+	render(w, r, "pages/home.html", "home.html", data)
 }
 
 func SearchPage(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	cl := api.New()
 	list, _ := cl.SearchProperties(q) // TODO: handle error, flash message
+	annotateShortlisted(r, list.Items)
 	w.Header().Set("Content-Type", "text/html")
 	t := template.Must(template.New("pages/search-results.html").Funcs(template.FuncMap{
 		"eq":          func(a, b any) bool { return a == b },
@@ -96,7 +113,8 @@ func SearchPage(w http.ResponseWriter, r *http.Request) {
 		"sub":         sub,
 		"seq":         seq,
 		"dict":        dict,
-	}).ParseFiles(
+		"join":        strings.Join,
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/search-results.html",
 		"internal/views/partials/page-header.html",
@@ -114,13 +132,16 @@ func SearchPage(w http.ResponseWriter, r *http.Request) {
 		"internal/views/partials/property-card.html",
 		"internal/views/partials/property-badge.html",
 		"internal/views/partials/pagination.html",
+		"internal/views/partials/recently-viewed.html",
 	))
 	data := withSearchData(r, map[string]any{
-		"List":             list,
-		"Query":            q,
-		"ActivePage":       "search",
-		"ShowResults":      true,
-		"ShortlistEnabled": true,
+		"List":              list,
+		"Query":             q,
+		"ActivePage":        "search",
+		"ShowResults":       true,
+		"ShortlistEnabled":  true,
+		"SaveSearchEnabled": true,
+		"RecentlyViewed":    loadRecentlyViewed(r, recentStripSize, ""),
 	})
 	data["GetStartedURL"] = getStartedURL()
 	data = withTopAreas(data)
@@ -153,58 +174,35 @@ func PropertiesPage(w http.ResponseWriter, r *http.Request) {
 			return list.Items[i].Price > list.Items[j].Price
 		})
 	}
+	annotateShortlisted(r, list.Items)
 	w.Header().Set("Content-Type", "text/html")
-	mapToken := strings.TrimSpace(os.Getenv("MAPBOX_PUBLIC_TOKEN"))
-	mapStyle := strings.TrimSpace(os.Getenv("MAPBOX_STYLE_URL"))
-	if mapStyle == "" {
-		mapStyle = "mapbox://styles/mapbox/streets-v12"
-	}
+	mapCfg := config.Get().Map
 	data := withSearchData(r, map[string]any{
 		"ActivePage":     "properties",
 		"List":           list,
 		"Query":          q,
-		"MapEnabled":     mapToken != "",
-		"MapboxToken":    mapToken,
-		"MapboxStyle":    mapStyle,
-		"MapDefaultLat":  envFloat("MAP_DEFAULT_LAT", 23.810332),
-		"MapDefaultLng":  envFloat("MAP_DEFAULT_LNG", 90.412521),
-		"MapDefaultZoom": envFloat("MAP_DEFAULT_ZOOM", 11.2),
+		"MapEnabled":     mapCfg.MapboxToken != "",
+		"MapboxToken":    mapCfg.MapboxToken,
+		"MapboxStyle":    mapCfg.MapboxStyle,
+		"MapDefaultLat":  mapCfg.DefaultLat,
+		"MapDefaultLng":  mapCfg.DefaultLng,
+		"MapDefaultZoom": mapCfg.DefaultZoom,
 	})
 	data["GetStartedURL"] = getStartedURL()
-	render(w, "pages/properties.html", "properties.html", data)
+	render(w, r, "pages/properties.html", "properties.html", data)
 }
 
 func PropertyPage(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	cl := api.New()
 	p, _ := cl.GetProperty(id) // TODO: handle error
-
-	docs, _ := cl.GetRequiredDocuments(p.Type)
-
-	enquiryEmail := strings.TrimSpace(os.Getenv("PROPERY_ENQUIRY_EMAIL"))
-	if enquiryEmail == "" {
-		enquiryEmail = "enquiry@dhakahome.com"
+	if p.ID != "" {
+		recordRecentlyViewed(w, r, p.ID)
 	}
 
-	contactEmail := enquiryEmail
-	if candidate := strings.TrimSpace(p.ContactEmail); candidate != "" {
-		contactEmail = candidate
-	}
+	docs, _ := cl.GetRequiredDocuments(p.Type)
 
-	contactPhone := defaultContactPhone(p.ListingType)
-	if contactPhone == "" {
-		contactPhone = strings.TrimSpace(p.ContactPhone)
-		if contactPhone != "" {
-			if normalized, err := normalizeBDPhone(contactPhone); err == nil {
-				contactPhone = normalized
-			}
-		}
-	}
-	if contactPhone == "" {
-		if normalized, err := normalizeBDPhone("01877-721-579"); err == nil {
-			contactPhone = normalized
-		}
-	}
+	contactEmail, contactPhone := propertyContact(p)
 
 	similarQuery := url.Values{}
 	if p.Type != "" {
@@ -247,6 +245,11 @@ func PropertyPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	annotated := append([]api.Property{p}, similar.Items...)
+	annotateShortlisted(r, annotated)
+	p = annotated[0]
+	copy(similar.Items, annotated[1:])
+
 	data := withSearchData(r, map[string]any{
 		"P":               p,
 		"Similar":         similar,
@@ -258,9 +261,11 @@ func PropertyPage(w http.ResponseWriter, r *http.Request) {
 		"Documents":       docs,
 		"ContactEmail":    contactEmail,
 		"ContactPhone":    contactPhone,
+		"Watching":        isWatching(r, p.ID),
+		"SpamGuard":       spamGuardData(),
 	})
 	data["GetStartedURL"] = getStartedURL()
-	render(w, "pages/property.html", "property.html", data)
+	render(w, r, "pages/property.html", "property.html", data)
 }
 
 func FAQPage(w http.ResponseWriter, r *http.Request) {
@@ -268,7 +273,7 @@ func FAQPage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	t := template.Must(template.New("pages/faq.html").Funcs(template.FuncMap{
 		"eq": func(a, b any) bool { return a == b },
-	}).ParseFiles(
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/faq.html",
 		"internal/views/partials/page-header.html",
@@ -289,7 +294,7 @@ func AboutUsPage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	t := template.Must(template.New("pages/about-us.html").Funcs(template.FuncMap{
 		"eq": func(a, b any) bool { return a == b },
-	}).ParseFiles(
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/about-us.html",
 		"internal/views/partials/page-header.html",
@@ -310,7 +315,7 @@ func HotelsPage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 	t := template.Must(template.New("pages/hotels.html").Funcs(template.FuncMap{
 		"eq": func(a, b any) bool { return a == b },
-	}).ParseFiles(
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/hotels.html",
 		"internal/views/partials/page-header.html",
@@ -332,15 +337,17 @@ func ContactUsPage(w http.ResponseWriter, r *http.Request) {
 	contactEmail := defaultContactEmail()
 	t := template.Must(template.New("pages/contact-us.html").Funcs(template.FuncMap{
 		"eq": func(a, b any) bool { return a == b },
-	}).ParseFiles(
+	}).Funcs(requestFuncs(r)).ParseFiles(
 		"internal/views/layouts/base.html",
 		"internal/views/pages/contact-us.html",
 		"internal/views/partials/page-header.html",
 		"internal/views/partials/header.html",
+		"internal/views/partials/spam-guard.html",
 	))
 	data := map[string]any{
 		"ActivePage":   "contact",
 		"ContactEmail": contactEmail,
+		"SpamGuard":    spamGuardData(),
 	}
 	data["GetStartedURL"] = getStartedURL()
 	if err := t.ExecuteTemplate(w, "pages/contact-us.html", data); err != nil {
@@ -545,21 +552,7 @@ func seq(start, end int) []int {
 	return result
 }
 
+// getStartedURL is the hero CTA: the requirement form unless GET_STARTED_URL overrides it.
 func getStartedURL() string {
-	val := strings.TrimSpace(os.Getenv("PORTAL_BASE_URL"))
-	if val == "" {
-		return "/"
-	}
-	return val
-}
-
-func envFloat(key string, def float64) float64 {
-	val := strings.TrimSpace(os.Getenv(key))
-	if val == "" {
-		return def
-	}
-	if parsed, err := strconv.ParseFloat(val, 64); err == nil {
-		return parsed
-	}
-	return def
+	return config.Get().GetStartedURL
 }
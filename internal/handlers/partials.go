@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/ratelimit"
 )
 
 func getProjectRoot() string {
@@ -42,11 +44,20 @@ func SubmitLead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := api.New()
+	verdict, captchaOK := screenLead(r, clean)
+	if !captchaOK {
+		writeLeadError(w, respondJSON, http.StatusBadRequest, map[string]any{
+			"errors": map[string]string{"captcha": "Please complete the captcha and try again."},
+		})
+		return
+	}
+
 	contactEmail := strings.TrimSpace(clean.ContactEmail)
 	if contactEmail == "" {
 		contactEmail = defaultContactEmail()
 	}
+	// CaptchaToken stays empty: tokens are single-use and were verified above, while the
+	// outbox may deliver this lead much later.
 	req := api.LeadReq{
 		Name:         clean.Name,
 		Email:        clean.Email,
@@ -55,17 +66,8 @@ func SubmitLead(w http.ResponseWriter, r *http.Request) {
 		PropertyID:   clean.PropertyID,
 		ContactEmail: contactEmail,
 	}
-
-	if err := client.SubmitLead(req); err != nil {
-		log.Printf("lead submission failed: %v", err)
-		writeLeadError(w, respondJSON, http.StatusBadGateway, map[string]any{
-			"error": "could not submit lead",
-		})
-		return
-	}
-
-	// Create Nestlo lead for admin follow-up (skip when mock enabled)
-	if err := client.CreateNestloLead(api.NestloLeadPayload{
+	// Nestlo lead for admin follow-up
+	nestlo := api.NestloLeadPayload{
 		LeadType: deriveLeadType(clean.ListingType),
 		Source:   "web",
 		ClientInfo: api.NestloLeadClientInfo{
@@ -76,12 +78,65 @@ func SubmitLead(w http.ResponseWriter, r *http.Request) {
 		},
 		Notes:   clean.Message,
 		AssetID: clean.PropertyID,
-	}); err != nil {
-		log.Printf("nestlo lead creation failed (non-blocking): %v", err)
 	}
+	attributeLead(r, &req, &nestlo)
+
+	// Suspected spam is kept for review and answered like any other lead, so bots learn nothing.
+	if verdict.Score >= leadSpamThreshold() {
+		quarantineLead(r, "lead", verdict, map[string]any{"lead": req, "nestlo": nestlo})
+		if respondJSON {
+			writeLeadJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// The same person asking about the same listing again is one lead, not several.
+	dedupeKeys := ratelimit.DedupeKeys(clean.PropertyID, clean.Phone, clean.Email)
+	if prev, ok := leadDedupe().Recent(dedupeKeys, time.Now()); ok {
+		mergeRepeatLead(r, prev, dedupeKeys, clean)
+		if respondJSON {
+			writeLeadJSON(w, http.StatusOK, map[string]any{"status": "ok", "reference": prev.Reference, "duplicate": true})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	prop := leadProperty(clean.PropertyID)
+	assigned := routeLead(leadSubject(prop, clean.ListingType), &req, &nestlo)
+
+	// Both deliveries go through the outbox: once it is on disk the visitor gets an answer
+	// and the worker retries Nestlo for as long as it takes.
+	leadID, err := queueLead(&req, &nestlo)
+	if err != nil {
+		log.Printf("lead outbox enqueue failed: %v", err)
+		writeLeadError(w, respondJSON, http.StatusServiceUnavailable, map[string]any{
+			"error": "could not submit lead, please try again",
+		})
+		return
+	}
+	recordLeadAttribution(r, leadID, "lead")
+	leadDedupe().Remember(dedupeKeys, ratelimit.Submission{
+		Reference: leadID,
+		Message:   strings.TrimSpace(clean.Message),
+		Owner:     assigned.Email,
+		At:        time.Now(),
+	})
+
+	mail := newMailLead(r, leadID, "lead")
+	mail.Name, mail.Email, mail.Phone, mail.Message = clean.Name, clean.Email, clean.Phone, clean.Message
+	mail.PropertyID = clean.PropertyID
+	if prop.ID != "" {
+		mail.PropertyTitle = prop.Title
+		mail.PropertyURL = siteURL(r) + "/properties/" + prop.ID
+	}
+	mail.AssignedTo = assigned.Label()
+	queueLeadMail(mail, assigned, true)
 
 	if respondJSON {
-		writeLeadJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+		writeLeadJSON(w, http.StatusOK, map[string]any{"status": "ok", "reference": leadID})
 		return
 	}
 
@@ -96,6 +151,10 @@ type leadPayload struct {
 	PropertyID   string `json:"propertyId"`
 	ContactEmail string `json:"contactEmail"`
 	ListingType  string `json:"listingType"`
+	// Spam defence: Website is a honeypot, FormToken the signed render time.
+	Website      string `json:"website"`
+	FormToken    string `json:"formToken"`
+	CaptchaToken string `json:"captchaToken"`
 }
 
 var emailRegex = regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
@@ -132,6 +191,14 @@ func parseLeadPayload(r *http.Request) (leadPayload, error) {
 		PropertyID:   r.FormValue("propertyId"),
 		ContactEmail: r.FormValue("contactEmail"),
 		ListingType:  r.FormValue("listingType"),
+		Website:      r.FormValue("website"),
+		FormToken:    r.FormValue("formToken"),
+		CaptchaToken: firstNonEmpty(
+			r.FormValue("captchaToken"),
+			r.FormValue("cf-turnstile-response"),
+			r.FormValue("h-captcha-response"),
+			r.FormValue("g-recaptcha-response"),
+		),
 	}, nil
 }
 
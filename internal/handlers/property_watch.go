@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/propertywatch"
+	"github.com/go-chi/chi/v5"
+)
+
+const propertyWatchMaxPerUser = 50
+
+var (
+	propertyWatchOnce  sync.Once
+	propertyWatchStore propertywatch.Store
+)
+
+func propertyWatches() propertywatch.Store {
+	propertyWatchOnce.Do(func() {
+		propertyWatchStore = propertywatch.NewFromEnv()
+	})
+	return propertyWatchStore
+}
+
+// StartPropertyWatcher runs the price/status watcher in the background until ctx is
+// done. The returned channel closes once the current run has finished.
+func StartPropertyWatcher(ctx context.Context) <-chan struct{} {
+	return runBackground(func() { propertywatch.NewWatcherFromEnv(propertyWatches()).Run(ctx) })
+}
+
+type propertyWatchPayload struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+}
+
+func propertyWatchJSON(w propertywatch.Watch) map[string]any {
+	return map[string]any{
+		"assetId":   w.AssetID,
+		"title":     w.Title,
+		"url":       "/properties/" + w.AssetID,
+		"channel":   w.Channel,
+		"to":        w.To,
+		"price":     w.Last.Price,
+		"status":    propertywatch.StatusLabel(w.Last.Status),
+		"watching":  true,
+		"createdAt": w.CreatedAt,
+	}
+}
+
+// isWatching reports whether the signed-in visitor watches assetID.
+func isWatching(r *http.Request, assetID string) bool {
+	token := shortlistToken(r)
+	if token == "" || assetID == "" {
+		return false
+	}
+	_, ok := propertyWatches().Get(shortlistUserKey(r, token), assetID)
+	return ok
+}
+
+// WatchProperty starts watching a listing for price and status changes.
+// The current listing becomes the baseline, so the first alert is a real change.
+func WatchProperty(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var in propertyWatchPayload
+	if r.ContentLength != 0 {
+		defer r.Body.Close()
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&in); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	channel, to, err := alertRecipient(r, in.Channel, in.To)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	assetID := strings.TrimSpace(chi.URLParam(r, "id"))
+	prop, err := api.New().GetProperty(assetID)
+	if err != nil || prop.ID == "" {
+		http.Error(w, "property not found", http.StatusNotFound)
+		return
+	}
+
+	owner := shortlistUserKey(r, token)
+	if _, exists := propertyWatches().Get(owner, prop.ID); !exists && len(propertyWatches().ListByOwner(owner)) >= propertyWatchMaxPerUser {
+		http.Error(w, "you can watch up to 50 properties - stop watching one first", http.StatusConflict)
+		return
+	}
+
+	watch, err := propertyWatches().Put(propertywatch.Watch{
+		OwnerKey: owner,
+		AssetID:  prop.ID,
+		Title:    prop.Title,
+		Channel:  channel,
+		To:       to,
+		Last:     propertywatch.SnapshotOf(prop),
+	})
+	if err != nil {
+		log.Printf("property watch create: %v", err)
+		http.Error(w, "unable to watch property", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, propertyWatchJSON(watch))
+}
+
+// UnwatchProperty stops watching a listing.
+func UnwatchProperty(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	assetID := strings.TrimSpace(chi.URLParam(r, "id"))
+	err := propertyWatches().Delete(shortlistUserKey(r, token), assetID)
+	if err != nil && !errors.Is(err, propertywatch.ErrNotFound) {
+		log.Printf("property watch delete: %v", err)
+		http.Error(w, "unable to stop watching", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"assetId": assetID, "watching": false})
+}
+
+// ListPropertyWatches returns every listing the user watches.
+func ListPropertyWatches(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	watches := propertyWatches().ListByOwner(shortlistUserKey(r, token))
+	out := make([]map[string]any, 0, len(watches))
+	for _, watch := range watches {
+		out = append(out, propertyWatchJSON(watch))
+	}
+	writeJSON(w, map[string]any{"watches": out})
+}
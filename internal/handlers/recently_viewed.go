@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+)
+
+const (
+	recentCookie    = "dh_recent"
+	recentCookieAge = 90 * 24 * time.Hour
+	recentMax       = 12
+	recentStripSize = 6
+)
+
+// recentlyViewedIDs returns the visitor's history, newest first: from the session when
+// logged in, otherwise from the signed guest cookie.
+func recentlyViewedIDs(r *http.Request) []string {
+	if sess, ok := session.FromRequest(r); ok {
+		return sess.RecentlyViewed
+	}
+	return readRecentCookie(r)
+}
+
+func readRecentCookie(r *http.Request) []string {
+	raw, ok := session.GetSigned(r, recentCookie)
+	if !ok || raw == "" {
+		return nil
+	}
+	return cleanAssetIDs(strings.Split(raw, ","), recentMax)
+}
+
+func writeRecentlyViewed(w http.ResponseWriter, r *http.Request, ids []string) {
+	ids = cleanAssetIDs(ids, recentMax)
+	if session.Update(r, func(s *session.Session) { s.RecentlyViewed = ids }) {
+		return
+	}
+	if len(ids) == 0 {
+		session.Clear(w, r, recentCookie)
+		return
+	}
+	session.SetSigned(w, r, recentCookie, strings.Join(ids, ","), recentCookieAge)
+}
+
+// recordRecentlyViewed moves assetID to the front of the visitor's history.
+func recordRecentlyViewed(w http.ResponseWriter, r *http.Request, assetID string) {
+	assetID = strings.TrimSpace(assetID)
+	if assetID == "" {
+		return
+	}
+	writeRecentlyViewed(w, r, append([]string{assetID}, recentlyViewedIDs(r)...))
+}
+
+// takeGuestRecentlyViewed hands the guest cookie history to a new session and clears the cookie.
+func takeGuestRecentlyViewed(w http.ResponseWriter, r *http.Request) []string {
+	ids := readRecentCookie(r)
+	if len(ids) > 0 {
+		session.Clear(w, r, recentCookie)
+	}
+	return ids
+}
+
+// loadRecentlyViewed fetches fresh listings for the history, skipping excludeID and
+// anything that has since been leased, sold or removed.
+func loadRecentlyViewed(r *http.Request, limit int, excludeID string) []api.Property {
+	ids := make([]string, 0, recentMax)
+	for _, id := range recentlyViewedIDs(r) {
+		if id != excludeID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	props := make([]api.Property, 0, len(ids))
+	for _, p := range fetchProperties(ids) {
+		if !p.IsAvailable() {
+			continue
+		}
+		props = append(props, p)
+		if limit > 0 && len(props) >= limit {
+			break
+		}
+	}
+	return props
+}
+
+// RecentPage renders /recent with the visitor's full history.
+func RecentPage(w http.ResponseWriter, r *http.Request) {
+	props := loadRecentlyViewed(r, 0, "")
+	// Forget listings that are gone so the history does not fill up with dead entries.
+	if kept := propertyIDs(props); len(kept) != len(recentlyViewedIDs(r)) {
+		writeRecentlyViewed(w, r, kept)
+	}
+	annotateShortlisted(r, props)
+
+	w.Header().Set("Content-Type", "text/html")
+	render(w, r, "pages/recent.html", "recent.html", map[string]any{
+		"ActivePage":       "properties",
+		"Recent":           props,
+		"ShortlistEnabled": true,
+	})
+}
+
+// ClearRecentlyViewed wipes the visitor's history.
+func ClearRecentlyViewed(w http.ResponseWriter, r *http.Request) {
+	writeRecentlyViewed(w, r, nil)
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"cleared": true})
+		return
+	}
+	http.Redirect(w, r, "/recent", http.StatusSeeOther)
+}
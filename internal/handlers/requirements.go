@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/mailer"
+	"github.com/BohoBytes/dhakahome-web/internal/routing"
+)
+
+const (
+	requirementMaxLocations = 10
+	requirementMaxNotes     = 2000
+	// requirementMaxBudget (৳10,000 crore) is far above any listing; larger is a typo.
+	requirementMaxBudget = 1e11
+)
+
+// requirementAmenities are the amenities a visitor can ask for; they match the labels
+// Nestlo uses on listings.
+var requirementAmenities = []string{
+	"Gas Supply", "Power Backup", "Lift", "Parking", "Furnished",
+	"Servant Room", "Kitchen Cabinet", "Boundary Wall", "Security", "Rooftop Access",
+}
+
+// requirementPayload is the "Tell us what you need" form.
+type requirementPayload struct {
+	ListingType   string   `json:"listingType"`
+	PropertyTypes []string `json:"propertyTypes"`
+	Locations     []string `json:"locations"`
+	BudgetMin     string   `json:"budgetMin"`
+	BudgetMax     string   `json:"budgetMax"`
+	Bedrooms      string   `json:"bedrooms"`
+	Bathrooms     string   `json:"bathrooms"`
+	Amenities     []string `json:"amenities"`
+	MoveInDate    string   `json:"moveInDate"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	Phone         string   `json:"phone"`
+	Notes         string   `json:"notes"`
+	Website       string   `json:"website"`
+	FormToken     string   `json:"formToken"`
+	CaptchaToken  string   `json:"captchaToken"`
+}
+
+// RequirementPage renders the requirement form, pre-filled from search parameters so a
+// zero-result search carries straight over.
+func RequirementPage(w http.ResponseWriter, r *http.Request) {
+	renderRequirementPage(w, r, http.StatusOK, requirementFromQuery(r.URL.Query()), nil)
+}
+
+// SubmitRequirement validates the form and queues a Nestlo lead with Requirements set.
+func SubmitRequirement(w http.ResponseWriter, r *http.Request) {
+	respondJSON := wantsJSON(r)
+
+	in, err := parseRequirementPayload(r)
+	if err != nil {
+		writeLeadError(w, respondJSON, http.StatusBadRequest, map[string]any{
+			"error": "invalid request body",
+		})
+		return
+	}
+
+	clean, reqs, errs := validateRequirement(in, time.Now())
+	if len(errs) > 0 {
+		if respondJSON {
+			writeLeadJSON(w, http.StatusBadRequest, map[string]any{"errors": errs})
+			return
+		}
+		renderRequirementPage(w, r, http.StatusBadRequest, in, errs)
+		return
+	}
+
+	verdict, captchaOK := screenLead(r, leadPayload{
+		Name:         clean.Name,
+		Email:        clean.Email,
+		Message:      clean.Notes,
+		Website:      clean.Website,
+		FormToken:    clean.FormToken,
+		CaptchaToken: clean.CaptchaToken,
+	})
+	if !captchaOK {
+		errs := map[string]string{"captcha": "Please complete the captcha and try again."}
+		if respondJSON {
+			writeLeadJSON(w, http.StatusBadRequest, map[string]any{"errors": errs})
+			return
+		}
+		renderRequirementPage(w, r, http.StatusBadRequest, in, errs)
+		return
+	}
+
+	nestlo := api.NestloLeadPayload{
+		LeadType: deriveLeadType(clean.ListingType),
+		Source:   "web",
+		ClientInfo: api.NestloLeadClientInfo{
+			Name:                   clean.Name,
+			Email:                  clean.Email,
+			Phone:                  clean.Phone,
+			PreferredContactMethod: preferredContactMethod(clean.Phone, clean.Email),
+		},
+		Requirements: &reqs,
+		Notes:        clean.Notes,
+	}
+	attributeLead(r, nil, &nestlo)
+
+	if verdict.Score >= leadSpamThreshold() {
+		quarantineLead(r, "requirements", verdict, map[string]any{"nestlo": nestlo})
+		requirementDone(w, r, respondJSON, "")
+		return
+	}
+
+	assigned := routeLead(requirementSubject(clean.ListingType, reqs), nil, &nestlo)
+
+	leadID, err := queueLead(nil, &nestlo)
+	if err != nil {
+		log.Printf("requirement lead enqueue failed: %v", err)
+		writeLeadError(w, respondJSON, http.StatusServiceUnavailable, map[string]any{
+			"error": "could not submit your request, please try again",
+		})
+		return
+	}
+	recordLeadAttribution(r, leadID, "requirements")
+
+	mail := newMailLead(r, leadID, "requirements")
+	mail.Name, mail.Email, mail.Phone, mail.Message = clean.Name, clean.Email, clean.Phone, clean.Notes
+	mail.Details = requirementDetails(clean.ListingType, reqs)
+	mail.AssignedTo = assigned.Label()
+	queueLeadMail(mail, assigned, true)
+
+	requirementDone(w, r, respondJSON, leadID)
+}
+
+// requirementSubject routes on the first preferred area and property type, and on the
+// top of the budget so a wide band lands with the desk that handles its upper end.
+func requirementSubject(listingType string, reqs api.NestloLeadRequirements) routing.Subject {
+	s := routing.Subject{ListingType: listingType, Price: reqs.BudgetMax}
+	if s.Price == 0 {
+		s.Price = reqs.BudgetMin
+	}
+	if len(reqs.Locations) > 0 {
+		s.Neighborhood = reqs.Locations[0]
+	}
+	if len(reqs.PropertyTypes) > 0 {
+		s.PropertyType = reqs.PropertyTypes[0]
+	}
+	return s
+}
+
+// requirementDetails summarises the request for the lead emails.
+func requirementDetails(listingType string, reqs api.NestloLeadRequirements) []mailer.Detail {
+	var out []mailer.Detail
+	add := func(label, value string) {
+		if value != "" {
+			out = append(out, mailer.Detail{Label: label, Value: value})
+		}
+	}
+	switch normalizeRequirementListingType(listingType) {
+	case "sale":
+		add("Looking to", "Buy")
+	case "rent":
+		add("Looking to", "Rent")
+	}
+	add("Property types", strings.Join(reqs.PropertyTypes, ", "))
+	add("Areas", strings.Join(reqs.Locations, ", "))
+	switch {
+	case reqs.BudgetMin > 0 && reqs.BudgetMax > 0:
+		add("Budget", "৳"+formatPrice(reqs.BudgetMin)+" - ৳"+formatPrice(reqs.BudgetMax))
+	case reqs.BudgetMax > 0:
+		add("Budget", "up to ৳"+formatPrice(reqs.BudgetMax))
+	case reqs.BudgetMin > 0:
+		add("Budget", "from ৳"+formatPrice(reqs.BudgetMin))
+	}
+	if reqs.Bedrooms > 0 {
+		add("Bedrooms", strconv.Itoa(reqs.Bedrooms)+"+")
+	}
+	if reqs.Bathrooms > 0 {
+		add("Bathrooms", strconv.Itoa(reqs.Bathrooms)+"+")
+	}
+	add("Amenities", strings.Join(reqs.Amenities, ", "))
+	add("Move-in", reqs.MoveInDate)
+	return out
+}
+
+func requirementDone(w http.ResponseWriter, r *http.Request, respondJSON bool, leadID string) {
+	if respondJSON {
+		out := map[string]any{"status": "ok"}
+		if leadID != "" {
+			out["reference"] = leadID
+		}
+		writeLeadJSON(w, http.StatusOK, out)
+		return
+	}
+	http.Redirect(w, r, "/requirements?sent=1", http.StatusSeeOther)
+}
+
+func renderRequirementPage(w http.ResponseWriter, r *http.Request, status int, in requirementPayload, errs map[string]string) {
+	listingType := normalizeRequirementListingType(in.ListingType)
+	selectedTypes := stringSet(in.PropertyTypes)
+	selectedAmenities := stringSet(in.Amenities)
+
+	var types, amenities []requirementOption
+	for _, opt := range typeOptions() {
+		if opt.Value == "" {
+			continue
+		}
+		types = append(types, requirementOption{Value: opt.Value, Label: opt.Label, Checked: selectedTypes[strings.ToLower(opt.Value)]})
+	}
+	for _, a := range requirementAmenities {
+		amenities = append(amenities, requirementOption{Value: a, Label: a, Checked: selectedAmenities[strings.ToLower(a)]})
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	render(w, r, "pages/requirements.html", "requirements.html", map[string]any{
+		"ActivePage":  "",
+		"Sent":        r.URL.Query().Get("sent") == "1" && status == http.StatusOK,
+		"Form":        in,
+		"ListingType": listingType,
+		"Locations":   strings.Join(in.Locations, ", "),
+		"Types":       types,
+		"Amenities":   amenities,
+		"Errors":      errs,
+		"Today":       time.Now().Format("2006-01-02"),
+		"SpamGuard":   spamGuardData(),
+	})
+}
+
+type requirementOption struct {
+	Value   string
+	Label   string
+	Checked bool
+}
+
+// requirementFromQuery maps search parameters (as used on /search) onto the form.
+func requirementFromQuery(q url.Values) requirementPayload {
+	var locations []string
+	for _, key := range []string{"neighborhood", "area", "location", "q", "city"} {
+		if v := strings.TrimSpace(q.Get(key)); v != "" && !strings.EqualFold(v, "any") {
+			locations = append(locations, v)
+		}
+	}
+	var types []string
+	for _, v := range strings.Split(firstNonEmpty(q.Get("types"), q.Get("type")), ",") {
+		if v = strings.TrimSpace(v); v != "" && !strings.EqualFold(v, "any") {
+			types = append(types, v)
+		}
+	}
+	return requirementPayload{
+		ListingType:   firstNonEmpty(q.Get("listing_type"), q.Get("listingType"), deriveListingTypeFromStatus(q.Get("status"))),
+		PropertyTypes: types,
+		Locations:     dedupeFold(locations),
+		BudgetMin:     normalizePriceValue(firstNonEmpty(q.Get("price_min"), q.Get("minPrice"))),
+		BudgetMax:     normalizePriceValue(firstNonEmpty(q.Get("price_max"), q.Get("maxPrice"))),
+		Bedrooms:      sanitizeSelection(q.Get("bedrooms")),
+		Bathrooms:     sanitizeSelection(q.Get("bathrooms")),
+	}
+}
+
+// requirementURL links a search to the requirement form with the same filters.
+func requirementURL(q url.Values) string {
+	keep := url.Values{}
+	for _, key := range []string{"listing_type", "listingType", "status", "type", "types", "neighborhood", "area", "location", "q", "city", "price_min", "minPrice", "price_max", "maxPrice", "bedrooms", "bathrooms"} {
+		if v := strings.TrimSpace(q.Get(key)); v != "" {
+			keep.Set(key, v)
+		}
+	}
+	if len(keep) == 0 {
+		return "/requirements"
+	}
+	return "/requirements?" + keep.Encode()
+}
+
+func parseRequirementPayload(r *http.Request) (requirementPayload, error) {
+	ct := strings.ToLower(r.Header.Get("Content-Type"))
+	if strings.Contains(ct, "application/json") {
+		defer r.Body.Close()
+		var payload requirementPayload
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+			return requirementPayload{}, err
+		}
+		return payload, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return requirementPayload{}, err
+	}
+	return requirementPayload{
+		ListingType:   r.FormValue("listingType"),
+		PropertyTypes: r.Form["propertyTypes"],
+		Locations:     strings.Split(r.FormValue("locations"), ","),
+		BudgetMin:     r.FormValue("budgetMin"),
+		BudgetMax:     r.FormValue("budgetMax"),
+		Bedrooms:      r.FormValue("bedrooms"),
+		Bathrooms:     r.FormValue("bathrooms"),
+		Amenities:     r.Form["amenities"],
+		MoveInDate:    r.FormValue("moveInDate"),
+		Name:          r.FormValue("name"),
+		Email:         r.FormValue("email"),
+		Phone:         r.FormValue("phone"),
+		Notes:         r.FormValue("notes"),
+		Website:       r.FormValue("website"),
+		FormToken:     r.FormValue("formToken"),
+		CaptchaToken: firstNonEmpty(
+			r.FormValue("captchaToken"),
+			r.FormValue("cf-turnstile-response"),
+			r.FormValue("h-captcha-response"),
+			r.FormValue("g-recaptcha-response"),
+		),
+	}, nil
+}
+
+// validateRequirement cleans the form and builds the Nestlo requirements. Errors are keyed
+// by form field name.
+func validateRequirement(in requirementPayload, now time.Time) (requirementPayload, api.NestloLeadRequirements, map[string]string) {
+	errs := make(map[string]string)
+	var reqs api.NestloLeadRequirements
+
+	in.Name = strings.TrimSpace(in.Name)
+	in.Email = strings.TrimSpace(in.Email)
+	in.Notes = strings.TrimSpace(in.Notes)
+
+	in.ListingType = normalizeRequirementListingType(in.ListingType)
+	if in.ListingType == "" {
+		errs["listingType"] = "Tell us whether you want to rent or buy."
+	}
+
+	allowedTypes := map[string]string{}
+	for _, opt := range typeOptions() {
+		if opt.Value != "" {
+			allowedTypes[strings.ToLower(opt.Value)] = opt.Value
+		}
+	}
+	for _, t := range in.PropertyTypes {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		canonical, ok := allowedTypes[strings.ToLower(t)]
+		if !ok {
+			errs["propertyTypes"] = "Please pick property types from the list."
+			break
+		}
+		reqs.PropertyTypes = append(reqs.PropertyTypes, canonical)
+	}
+	reqs.PropertyTypes = dedupeFold(reqs.PropertyTypes)
+
+	for _, loc := range in.Locations {
+		if loc = strings.TrimSpace(loc); loc != "" {
+			reqs.Locations = append(reqs.Locations, loc)
+		}
+	}
+	reqs.Locations = dedupeFold(reqs.Locations)
+	switch {
+	case len(reqs.Locations) == 0:
+		errs["locations"] = "Please add at least one area you are interested in."
+	case len(reqs.Locations) > requirementMaxLocations:
+		errs["locations"] = "Please list at most " + strconv.Itoa(requirementMaxLocations) + " areas."
+	default:
+		for _, loc := range reqs.Locations {
+			if len(loc) > 80 {
+				errs["locations"] = "Area names must be shorter than 80 characters."
+				break
+			}
+		}
+	}
+
+	var okMin, okMax bool
+	if reqs.BudgetMin, okMin = parseBudget(in.BudgetMin); !okMin {
+		errs["budgetMin"] = "Enter the minimum budget as a number."
+	}
+	if reqs.BudgetMax, okMax = parseBudget(in.BudgetMax); !okMax {
+		errs["budgetMax"] = "Enter the maximum budget as a number."
+	}
+	if okMin && okMax && reqs.BudgetMax > 0 && reqs.BudgetMin > reqs.BudgetMax {
+		errs["budgetMax"] = "Maximum budget must be at least the minimum."
+	}
+
+	var ok bool
+	if reqs.Bedrooms, ok = parseRoomCount(in.Bedrooms); !ok {
+		errs["bedrooms"] = "Bedrooms must be between 0 and 20."
+	}
+	if reqs.Bathrooms, ok = parseRoomCount(in.Bathrooms); !ok {
+		errs["bathrooms"] = "Bathrooms must be between 0 and 20."
+	}
+
+	allowedAmenities := map[string]string{}
+	for _, a := range requirementAmenities {
+		allowedAmenities[strings.ToLower(a)] = a
+	}
+	for _, a := range in.Amenities {
+		if a = strings.TrimSpace(a); a == "" {
+			continue
+		}
+		canonical, ok := allowedAmenities[strings.ToLower(a)]
+		if !ok {
+			errs["amenities"] = "Please pick amenities from the list."
+			break
+		}
+		reqs.Amenities = append(reqs.Amenities, canonical)
+	}
+	reqs.Amenities = dedupeFold(reqs.Amenities)
+
+	if raw := strings.TrimSpace(in.MoveInDate); raw != "" {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		d, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		switch {
+		case err != nil:
+			errs["moveInDate"] = "Use a date like 2026-01-31."
+		case d.Before(today):
+			errs["moveInDate"] = "Move-in date cannot be in the past."
+		case d.After(today.AddDate(2, 0, 0)):
+			errs["moveInDate"] = "Move-in date must be within the next two years."
+		default:
+			reqs.MoveInDate = d.Format("2006-01-02")
+		}
+	}
+
+	if len(in.Name) < 2 {
+		errs["name"] = "Please enter your name."
+	}
+	if !emailRegex.MatchString(in.Email) {
+		errs["email"] = "Please enter a valid email."
+	}
+	if phone, err := normalizeBDPhone(in.Phone); err != nil {
+		errs["phone"] = err.Error()
+	} else {
+		in.Phone = phone
+	}
+	if len(in.Notes) > requirementMaxNotes {
+		errs["notes"] = "Please keep notes under " + strconv.Itoa(requirementMaxNotes) + " characters."
+	}
+
+	return in, reqs, errs
+}
+
+// normalizeRequirementListingType reduces listing types and statuses to "rent" or "sale".
+func normalizeRequirementListingType(v string) string {
+	lt := strings.ToLower(strings.TrimSpace(v))
+	lt = strings.ReplaceAll(strings.ReplaceAll(lt, " ", "_"), "-", "_")
+	switch lt {
+	case "listed_sale", "sale", "sell", "buyer", "buy", "for_sale":
+		return "sale"
+	case "listed_rental", "rent", "rental", "lease", "tenant", "to_let", "tolet":
+		return "rent"
+	}
+	return ""
+}
+
+// parseBudget accepts plain or comma-grouped taka amounts; empty means no limit. NaN,
+// infinities and amounts above requirementMaxBudget are rejected: ParseFloat accepts
+// them, but they cannot be JSON-encoded for Nestlo.
+func parseBudget(v string) (float64, bool) {
+	clean := strings.NewReplacer(",", "", "৳", "", " ", "", "tk", "", "Tk", "").Replace(strings.TrimSpace(v))
+	if clean == "" {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(clean, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || f > requirementMaxBudget {
+		return 0, false
+	}
+	return f, true
+}
+
+func parseRoomCount(v string) (int, bool) {
+	v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "+"))
+	if v == "" || strings.EqualFold(v, "any") {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > 20 {
+		return 0, false
+	}
+	return n, true
+}
+
+func stringSet(values []string) map[string]bool {
+	out := make(map[string]bool, len(values))
+	for _, v := range values {
+		out[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+	return out
+}
+
+// dedupeFold drops case-insensitive duplicates, keeping the first spelling.
+func dedupeFold(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		key := strings.ToLower(v)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+	return out
+}
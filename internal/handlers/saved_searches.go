@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/notify"
+	"github.com/BohoBytes/dhakahome-web/internal/savedsearch"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+const savedSearchMaxPerUser = 20
+
+var (
+	savedSearchOnce  sync.Once
+	savedSearchStore savedsearch.Store
+)
+
+func savedSearches() savedsearch.Store {
+	savedSearchOnce.Do(func() {
+		savedSearchStore = savedsearch.NewFromEnv()
+	})
+	return savedSearchStore
+}
+
+// StartSavedSearchAlerts runs the new-listing alert job in the background until ctx is
+// done. The returned channel closes once the current run has finished.
+func StartSavedSearchAlerts(ctx context.Context) <-chan struct{} {
+	return runBackground(func() { savedsearch.NewRunnerFromEnv(savedSearches()).Run(ctx) })
+}
+
+type savedSearchPayload struct {
+	Name    string `json:"name"`
+	Query   string `json:"query"`   // the search page's query string, with or without "?"
+	Channel string `json:"channel"` // email (default when known) or sms
+	To      string `json:"to"`      // only needed when the session has no email/phone
+}
+
+func savedSearchJSON(sr savedsearch.Search) map[string]any {
+	out := map[string]any{
+		"id":        sr.ID,
+		"name":      sr.Name,
+		"query":     sr.Query,
+		"url":       "/search?" + sr.Query,
+		"channel":   sr.Channel,
+		"to":        sr.To,
+		"createdAt": sr.CreatedAt,
+	}
+	if !sr.LastNotifiedAt.IsZero() {
+		out["lastNotifiedAt"] = sr.LastNotifiedAt
+	}
+	return out
+}
+
+// CreateSavedSearch stores the normalized form of the current search under a name.
+func CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	defer r.Body.Close()
+	var in savedSearchPayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&in); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if runes := []rune(in.Name); len(runes) > 80 {
+		in.Name = string(runes[:80])
+	}
+	raw, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(in.Query), "?"))
+	if err != nil {
+		http.Error(w, "invalid search query", http.StatusBadRequest)
+		return
+	}
+	channel, to, err := alertRecipient(r, in.Channel, in.To)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	owner := shortlistUserKey(r, token)
+	if len(savedSearches().ListByOwner(owner)) >= savedSearchMaxPerUser {
+		http.Error(w, "you can keep up to 20 saved searches - delete one first", http.StatusConflict)
+		return
+	}
+
+	sr, err := savedSearches().Create(savedsearch.Search{
+		OwnerKey: owner,
+		Name:     in.Name,
+		Query:    api.NormalizeSearchQuery(raw).Encode(),
+		Channel:  channel,
+		To:       to,
+	})
+	if err != nil {
+		log.Printf("saved search create: %v", err)
+		http.Error(w, "unable to save search", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, savedSearchJSON(sr))
+}
+
+// ListSavedSearches returns the user's saved searches.
+func ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	searches := savedSearches().ListByOwner(shortlistUserKey(r, token))
+	out := make([]map[string]any, 0, len(searches))
+	for _, sr := range searches {
+		out = append(out, savedSearchJSON(sr))
+	}
+	writeJSON(w, map[string]any{"searches": out})
+}
+
+// DeleteSavedSearch removes a saved search and stops its alerts.
+func DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	err := savedSearches().Delete(shortlistUserKey(r, token), chi.URLParam(r, "searchID"))
+	if errors.Is(err, savedsearch.ErrNotFound) {
+		http.Error(w, "saved search not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("saved search delete: %v", err)
+		http.Error(w, "unable to delete saved search", http.StatusInternalServerError)
+		return
+	}
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"deleted": true})
+		return
+	}
+	http.Redirect(w, r, "/saved-searches", http.StatusSeeOther)
+}
+
+// SavedSearchesPage lists the signed-in user's saved searches with links and delete buttons.
+func SavedSearchesPage(w http.ResponseWriter, r *http.Request) {
+	var searches []savedsearch.Search
+	token := shortlistToken(r)
+	if token != "" {
+		searches = savedSearches().ListByOwner(shortlistUserKey(r, token))
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	render(w, r, "pages/saved-searches.html", "saved-searches.html", map[string]any{
+		"ActivePage": "search",
+		"SignedIn":   token != "",
+		"Searches":   searches,
+	})
+}
+
+// alertRecipient picks where alerts go: the requested channel, using the session's
+// email or phone, or the address given in the payload when the session has none.
+func alertRecipient(r *http.Request, channel, to string) (string, string, error) {
+	var email, phone string
+	if sess, ok := session.FromRequest(r); ok {
+		email, phone = sess.Email, sess.Phone
+	}
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	to = strings.TrimSpace(to)
+
+	switch channel {
+	case "":
+		if email == "" && phone != "" && to == "" {
+			channel = notify.ChannelSMS
+		} else {
+			channel = notify.ChannelEmail
+		}
+	case notify.ChannelEmail, notify.ChannelSMS:
+	default:
+		return "", "", errors.New("channel must be email or sms")
+	}
+
+	if channel == notify.ChannelSMS {
+		if to == "" {
+			to = phone
+		}
+		normalized, err := normalizeBDPhone(to)
+		if err != nil {
+			return "", "", err
+		}
+		return channel, normalized, nil
+	}
+	if to == "" {
+		to = email
+	}
+	if !emailRegex.MatchString(to) {
+		return "", "", errors.New("Please provide a valid email for alerts.")
+	}
+	return channel, strings.ToLower(to), nil
+}
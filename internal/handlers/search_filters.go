@@ -51,6 +51,7 @@ func withSearchData(r *http.Request, data map[string]any) map[string]any {
 	if _, ok := data["Query"]; !ok {
 		data["Query"] = r.URL.Query()
 	}
+	data["RequirementURL"] = requirementURL(r.URL.Query())
 	return data
 }
 
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/shortlistexport"
+)
+
+// ExportShortlistCSV downloads the current shortlist (all pages) as CSV.
+func ExportShortlistCSV(w http.ResponseWriter, r *http.Request) {
+	rows, _, ok := shortlistExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := shortlistexport.WriteCSV(&buf, rows); err != nil {
+		log.Printf("shortlist export csv: %v", err)
+		http.Error(w, "unable to export shortlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", exportDisposition("csv"))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// ExportShortlistPDF downloads the current shortlist as a printable comparison sheet.
+func ExportShortlistPDF(w http.ResponseWriter, r *http.Request) {
+	rows, title, ok := shortlistExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := shortlistexport.WritePDF(&buf, shortlistexport.Sheet{
+		Title:       title,
+		GeneratedAt: time.Now(),
+		Rows:        rows,
+	})
+	if err != nil {
+		log.Printf("shortlist export pdf: %v", err)
+		http.Error(w, "unable to export shortlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", exportDisposition("pdf"))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// shortlistExportRows loads every item of the requested list (or the guest cookie list).
+// It writes the error response itself and reports false on failure.
+func shortlistExportRows(w http.ResponseWriter, r *http.Request) ([]shortlistexport.Row, string, bool) {
+	token := shortlistToken(r)
+	title := "DhakaHome shortlist"
+
+	var items []api.Property
+	if token == "" {
+		client := api.New()
+		for _, id := range readGuestShortlist(r) {
+			prop, err := client.GetProperty(id)
+			if err != nil {
+				log.Printf("shortlist export: skipping %s: %v", id, err)
+				continue
+			}
+			items = append(items, prop)
+		}
+	} else {
+		listID := strings.TrimSpace(r.URL.Query().Get("list"))
+		var err error
+		items, err = api.New().ListAllShortlistItems(token, listID)
+		if err != nil {
+			writeShortlistError(w, err, "unable to load shortlist")
+			return nil, "", false
+		}
+		applyShortlistMeta(r, token, items)
+		if listID != "" {
+			if lists, err := api.New().ListShortlists(token); err == nil {
+				for _, l := range lists {
+					if l.ID == listID {
+						title = "DhakaHome shortlist: " + l.Name
+						break
+					}
+				}
+			}
+		}
+	}
+
+	base := siteURL(r)
+	rows := make([]shortlistexport.Row, 0, len(items))
+	for _, p := range items {
+		email, phone := propertyContact(p)
+		row := shortlistexport.Row{
+			ID:           p.ID,
+			Title:        p.Title,
+			Address:      p.Address,
+			Type:         p.Type,
+			ListingType:  p.ListingType,
+			Price:        p.Price,
+			Currency:     p.Currency,
+			Area:         p.Area,
+			Bedrooms:     p.Bedrooms,
+			Bathrooms:    p.Bathrooms,
+			Parking:      p.Parking,
+			URL:          base + "/properties/" + p.ID,
+			ContactPhone: phone,
+			ContactEmail: email,
+			Notes:        p.ShortlistNotes,
+		}
+		if len(p.Images) > 0 {
+			row.Photo = p.Images[0]
+		}
+		rows = append(rows, row)
+	}
+	return rows, title, true
+}
+
+func exportDisposition(ext string) string {
+	return fmt.Sprintf(`attachment; filename="dhakahome-shortlist-%s.%s"`, time.Now().Format("2006-01-02"), ext)
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/go-chi/chi/v5"
+)
+
+type shortlistPayload struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type shortlistMovePayload struct {
+	To    string `json:"to"`
+	ToAlt string `json:"shortlistId"`
+}
+
+// ListShortlists returns the user's named shortlists.
+func ListShortlists(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := api.New().ListShortlists(token)
+	if err != nil {
+		writeShortlistError(w, err, "unable to load shortlists")
+		return
+	}
+
+	writeJSON(w, map[string]any{"shortlists": lists})
+}
+
+// CreateShortlist creates a new named shortlist, e.g. "Gulshan flats".
+func CreateShortlist(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	in, err := decodeShortlistPayload(r)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if in.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	list, err := api.New().CreateShortlist(token, in.Name, in.Description)
+	if err != nil {
+		writeShortlistError(w, err, "unable to create shortlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, list)
+}
+
+// RenameShortlist changes a shortlist's name.
+func RenameShortlist(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	in, err := decodeShortlistPayload(r)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if in.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	list, err := api.New().RenameShortlist(token, chi.URLParam(r, "shortlistID"), in.Name, in.Description)
+	if err != nil {
+		writeShortlistError(w, err, "unable to rename shortlist")
+		return
+	}
+
+	writeJSON(w, list)
+}
+
+// DeleteShortlist removes a named shortlist. The default list cannot be deleted.
+func DeleteShortlist(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	shortlistID := strings.TrimSpace(chi.URLParam(r, "shortlistID"))
+	if err := api.New().DeleteShortlist(token, shortlistID); err != nil {
+		writeShortlistError(w, err, "unable to delete shortlist")
+		return
+	}
+
+	writeJSON(w, map[string]any{"id": shortlistID, "deleted": true})
+}
+
+// AddNamedShortlistItem adds a property to a specific shortlist.
+func AddNamedShortlistItem(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	defer r.Body.Close()
+	var payload shortlistAddPayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	assetID := strings.TrimSpace(firstNonEmpty(payload.AssetID, payload.AssetIDAlt))
+	if assetID == "" {
+		http.Error(w, "assetId is required", http.StatusBadRequest)
+		return
+	}
+
+	status, err := api.New().AddToNamedShortlist(token, chi.URLParam(r, "shortlistID"), assetID)
+	if err != nil {
+		writeShortlistError(w, err, "unable to add to shortlist")
+		return
+	}
+	syncShortlistShares(r, token, status.ShortlistID, assetID, true)
+
+	writeShortlistStatus(w, status)
+}
+
+// RemoveNamedShortlistItem removes a property from one shortlist only.
+func RemoveNamedShortlistItem(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	status, err := api.New().RemoveFromNamedShortlist(token, chi.URLParam(r, "shortlistID"), chi.URLParam(r, "assetID"))
+	if err != nil {
+		writeShortlistError(w, err, "unable to remove from shortlist")
+		return
+	}
+	syncShortlistShares(r, token, status.ShortlistID, status.AssetID, false)
+
+	writeShortlistStatus(w, status)
+}
+
+// MoveShortlistItem moves a property from the shortlist in the URL to the one in the body.
+func MoveShortlistItem(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	defer r.Body.Close()
+	var payload shortlistMovePayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	to := strings.TrimSpace(firstNonEmpty(payload.To, payload.ToAlt))
+	if to == "" {
+		http.Error(w, "to is required", http.StatusBadRequest)
+		return
+	}
+
+	from, assetID := chi.URLParam(r, "shortlistID"), chi.URLParam(r, "assetID")
+	status, err := api.New().MoveShortlistItem(token, from, to, assetID)
+	if err != nil {
+		writeShortlistError(w, err, "unable to move shortlist item")
+		return
+	}
+	syncShortlistShares(r, token, from, assetID, false)
+	syncShortlistShares(r, token, to, assetID, true)
+
+	writeShortlistStatus(w, status)
+}
+
+func decodeShortlistPayload(r *http.Request) (shortlistPayload, error) {
+	defer r.Body.Close()
+	var in shortlistPayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&in); err != nil {
+		return shortlistPayload{}, err
+	}
+	in.Name = strings.TrimSpace(in.Name)
+	in.Description = strings.TrimSpace(in.Description)
+	if runes := []rune(in.Name); len(runes) > 80 {
+		in.Name = string(runes[:80])
+	}
+	return in, nil
+}
+
+func writeShortlistStatus(w http.ResponseWriter, status api.ShortlistStatus) {
+	writeJSON(w, map[string]any{
+		"assetId":       status.AssetID,
+		"shortlistId":   status.ShortlistID,
+		"shortlisted":   status.IsShortlisted,
+		"isShortlisted": status.IsShortlisted,
+	})
+}
+
+// writeShortlistError maps Nestlo errors to responses, passing through 4xx details.
+func writeShortlistError(w http.ResponseWriter, err error, fallback string) {
+	if isUnauthorized(err) {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
+		http.Error(w, apiErr.Message, apiErr.StatusCode)
+		return
+	}
+	http.Error(w, fallback, http.StatusBadGateway)
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+	"github.com/BohoBytes/dhakahome-web/internal/shortlistmeta"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	shortlistNotesMax = 1000
+	shortlistTagsMax  = 10
+	shortlistTagLen   = 30
+)
+
+var (
+	metaStoreOnce sync.Once
+	metaStore     shortlistmeta.Store
+)
+
+func shortlistMeta() shortlistmeta.Store {
+	metaStoreOnce.Do(func() {
+		metaStore = shortlistmeta.NewFromEnv()
+	})
+	return metaStore
+}
+
+type shortlistMetaPayload struct {
+	Notes  string   `json:"notes"`
+	Tags   []string `json:"tags"`
+	Rating int      `json:"rating"`
+}
+
+// shortlistFilter is the optional filter/sort applied to a shortlist view.
+type shortlistFilter struct {
+	Tag       string
+	MinRating int
+	Query     string
+	Sort      string
+}
+
+func (f shortlistFilter) active() bool {
+	return f.Tag != "" || f.MinRating > 0 || f.Query != "" || f.Sort != ""
+}
+
+func parseShortlistFilter(r *http.Request) shortlistFilter {
+	q := r.URL.Query()
+	f := shortlistFilter{
+		Tag:   normalizeTag(q.Get("tag")),
+		Query: strings.ToLower(strings.TrimSpace(q.Get("q"))),
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(q.Get("min_rating"))); err == nil && n > 0 && n <= 5 {
+		f.MinRating = n
+	}
+	switch s := strings.TrimSpace(q.Get("sort")); s {
+	case "added", "rating", "price", "price_desc":
+		f.Sort = s
+	}
+	return f
+}
+
+// UpdateShortlistMeta saves notes, tags and a rating for one shortlisted property. They are
+// kept locally: Nestlo has no call that updates an item's notes, and re-posting the item
+// would add it to the default list when it is not shortlisted.
+func UpdateShortlistMeta(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	assetID := strings.TrimSpace(chi.URLParam(r, "assetID"))
+	if assetID == "" {
+		http.Error(w, "assetID is required", http.StatusBadRequest)
+		return
+	}
+
+	defer r.Body.Close()
+	var payload shortlistMetaPayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	notes := strings.TrimSpace(payload.Notes)
+	if utf8.RuneCountInString(notes) > shortlistNotesMax {
+		http.Error(w, "notes must be 1000 characters or fewer", http.StatusBadRequest)
+		return
+	}
+	if payload.Rating < 0 || payload.Rating > 5 {
+		http.Error(w, "rating must be between 0 and 5", http.StatusBadRequest)
+		return
+	}
+	tags := cleanTags(payload.Tags)
+	if len(tags) > shortlistTagsMax {
+		http.Error(w, "up to 10 tags are allowed", http.StatusBadRequest)
+		return
+	}
+
+	meta := shortlistmeta.Meta{Notes: notes, Tags: tags, Rating: payload.Rating}
+	if err := shortlistMeta().Put(shortlistUserKey(r, token), assetID, meta); err != nil {
+		log.Printf("shortlist meta: save %s: %v", assetID, err)
+		http.Error(w, "unable to save shortlist notes", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"assetId": assetID,
+		"notes":   notes,
+		"tags":    tags,
+		"rating":  meta.Rating,
+	})
+}
+
+// applyShortlistMeta copies the user's local annotations onto shortlist items. A local
+// note wins over one Nestlo returns with the item.
+func applyShortlistMeta(r *http.Request, token string, items []api.Property) {
+	all := shortlistMeta().All(shortlistUserKey(r, token))
+	if len(all) == 0 {
+		return
+	}
+	for i := range items {
+		m, ok := all[items[i].ID]
+		if !ok {
+			continue
+		}
+		items[i].ShortlistTags = m.Tags
+		items[i].ShortlistRating = m.Rating
+		if m.Notes != "" {
+			items[i].ShortlistNotes = m.Notes
+		}
+	}
+}
+
+// filterShortlist filters and sorts the full shortlist, then cuts out one page.
+func filterShortlist(items []api.Property, f shortlistFilter, page, limit int) api.PropertyList {
+	kept := make([]api.Property, 0, len(items))
+	for _, p := range items {
+		if f.Tag != "" && !hasTag(p.ShortlistTags, f.Tag) {
+			continue
+		}
+		if f.MinRating > 0 && p.ShortlistRating < f.MinRating {
+			continue
+		}
+		if f.Query != "" && !strings.Contains(strings.ToLower(p.ShortlistNotes+" "+p.Title+" "+p.Address), f.Query) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	switch f.Sort {
+	case "added":
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].ShortlistedAt.After(kept[j].ShortlistedAt) })
+	case "rating":
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].ShortlistRating > kept[j].ShortlistRating })
+	case "price":
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Price < kept[j].Price })
+	case "price_desc":
+		sort.SliceStable(kept, func(i, j int) bool { return kept[i].Price > kept[j].Price })
+	}
+
+	total := len(kept)
+	pages := int(math.Ceil(float64(total) / float64(limit)))
+	if pages == 0 {
+		pages = 1
+	}
+	if page > pages {
+		page = pages
+	}
+	start := (page - 1) * limit
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	return api.PropertyList{
+		Items: kept[start:end],
+		Page:  page,
+		Pages: pages,
+		Total: total,
+	}
+}
+
+// shortlistTags collects every tag the user has used, for the filter dropdown.
+func shortlistTags(items []api.Property) []string {
+	seen := make(map[string]struct{})
+	tags := make([]string, 0)
+	for _, p := range items {
+		for _, t := range p.ShortlistTags {
+			if _, ok := seen[t]; ok {
+				continue
+			}
+			seen[t] = struct{}{}
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// shortlistUserKey identifies the user for the local meta store: the session user ID,
+// else the token's JWT subject, else a hash of the token.
+func shortlistUserKey(r *http.Request, token string) string {
+	if sess, ok := session.FromRequest(r); ok && sess.UserID != "" && sess.Token == token {
+		return "user:" + sess.UserID
+	}
+	if sub := jwtSubject(token); sub != "" {
+		return "user:" + sub
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
+// jwtSubject reads the unverified "sub" claim. It is only used as a storage key,
+// never for authorisation - Nestlo validates the token on every call.
+func jwtSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(claims.Sub)
+}
+
+func cleanTags(in []string) []string {
+	seen := make(map[string]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, t := range in {
+		t = normalizeTag(t)
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
+
+func normalizeTag(t string) string {
+	t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+	if runes := []rune(t); len(runes) > shortlistTagLen {
+		t = string(runes[:shortlistTagLen])
+	}
+	return t
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
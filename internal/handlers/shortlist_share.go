@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+	"github.com/BohoBytes/dhakahome-web/internal/shortlistshare"
+	"github.com/go-chi/chi/v5"
+)
+
+var (
+	shareStoreOnce sync.Once
+	shareStore     shortlistshare.Store
+)
+
+func shortlistShares() shortlistshare.Store {
+	shareStoreOnce.Do(func() {
+		shareStore = shortlistshare.NewFromEnv()
+	})
+	return shareStore
+}
+
+// shortlistShareTTL is how long a new share link stays valid unless the owner asks otherwise.
+func shortlistShareTTL() time.Duration {
+	return config.Get().Shortlists.ShareTTL
+}
+
+// shareTokenPrefix scopes share link signatures, so a value signed for another purpose
+// (a form token, say) is never accepted as a share link.
+const shareTokenPrefix = "shortlist-share:"
+
+type shortlistSharePayload struct {
+	ExpiresInDays int `json:"expiresInDays"`
+}
+
+// sharePath is the public link for a share. The ID is signed so links cannot be guessed.
+func sharePath(id string) string {
+	return "/shortlists/shared/" + url.PathEscape(session.Sign(shareTokenPrefix+id))
+}
+
+func shareJSON(sh shortlistshare.Share) map[string]any {
+	out := map[string]any{
+		"id":          sh.ID,
+		"shortlistId": sh.ShortlistID,
+		"name":        sh.Name,
+		"url":         sharePath(sh.ID),
+		"items":       len(sh.AssetIDs),
+		"createdAt":   sh.CreatedAt,
+		"updatedAt":   sh.UpdatedAt,
+		"active":      sh.Active(time.Now()) == nil,
+	}
+	if !sh.ExpiresAt.IsZero() {
+		out["expiresAt"] = sh.ExpiresAt
+	}
+	if !sh.RevokedAt.IsZero() {
+		out["revokedAt"] = sh.RevokedAt
+	}
+	return out
+}
+
+// CreateShortlistShare snapshots one of the user's shortlists into a shareable link.
+// Use "default" as the shortlist ID for the user's main list.
+func CreateShortlistShare(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var payload shortlistSharePayload
+	if r.Body != nil {
+		defer r.Body.Close()
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if payload.ExpiresInDays < 0 || payload.ExpiresInDays > 90 {
+		http.Error(w, "expiresInDays must be between 1 and 90", http.StatusBadRequest)
+		return
+	}
+
+	client := api.New()
+	lists, err := client.ListShortlists(token)
+	if err != nil {
+		writeShortlistError(w, err, "unable to load shortlists")
+		return
+	}
+	requested := strings.TrimSpace(chi.URLParam(r, "shortlistID"))
+	var target api.Shortlist
+	for _, l := range lists {
+		if l.ID == requested || (requested == "default" && l.IsDefault) {
+			target = l
+			break
+		}
+	}
+	if target.ID == "" {
+		http.Error(w, "shortlist not found", http.StatusNotFound)
+		return
+	}
+
+	ids, err := shortlistAssetIDs(client, token, target.ID)
+	if err != nil {
+		writeShortlistError(w, err, "unable to load shortlist")
+		return
+	}
+
+	ttl := shortlistShareTTL()
+	if payload.ExpiresInDays > 0 {
+		ttl = time.Duration(payload.ExpiresInDays) * 24 * time.Hour
+	}
+	ownerName := ""
+	if sess, ok := session.FromRequest(r); ok && sess.Token == token {
+		ownerName = sess.Name
+	}
+
+	sh, err := shortlistShares().Create(shortlistshare.Share{
+		OwnerKey:    shortlistUserKey(r, token),
+		OwnerName:   ownerName,
+		ShortlistID: target.ID,
+		Name:        target.Name,
+		AssetIDs:    ids,
+		ExpiresAt:   time.Now().Add(ttl).UTC(),
+	})
+	if err != nil {
+		log.Printf("shortlist share: create: %v", err)
+		http.Error(w, "unable to share shortlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, shareJSON(sh))
+}
+
+// ListShortlistShares returns the links the user has created for one shortlist.
+func ListShortlistShares(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	shortlistID := strings.TrimSpace(chi.URLParam(r, "shortlistID"))
+	if shortlistID == "default" {
+		shortlistID = ""
+	}
+	ownerKey := shortlistUserKey(r, token)
+	refreshed := map[string]bool{}
+	for _, sh := range shortlistShares().ListByOwner(ownerKey, shortlistID) {
+		if !refreshed[sh.ShortlistID] && sh.Active(time.Now()) == nil {
+			refreshed[sh.ShortlistID] = true
+			refreshShortlistShares(ownerKey, token, sh.ShortlistID)
+		}
+	}
+	shares := shortlistShares().ListByOwner(ownerKey, shortlistID)
+	out := make([]map[string]any, 0, len(shares))
+	for _, sh := range shares {
+		out = append(out, shareJSON(sh))
+	}
+	writeJSON(w, map[string]any{"shares": out})
+}
+
+// RevokeShortlistShare disables a share link immediately.
+func RevokeShortlistShare(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+	if token == "" {
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	shareID := strings.TrimSpace(chi.URLParam(r, "shareID"))
+	if err := shortlistShares().Revoke(shortlistUserKey(r, token), shareID); err != nil {
+		if errors.Is(err, shortlistshare.ErrNotFound) {
+			http.Error(w, "share not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("shortlist share: revoke %s: %v", shareID, err)
+		http.Error(w, "unable to revoke share", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"id": shareID, "revoked": true})
+}
+
+// SharedShortlistPage renders a shared shortlist, read-only, for anyone holding the link.
+// When the owner opens it the items are re-read from their Nestlo list first.
+func SharedShortlistPage(w http.ResponseWriter, r *http.Request) {
+	sh, status := loadShare(r)
+	if status != http.StatusOK {
+		sharedShortlistUnavailable(w, status)
+		return
+	}
+	if sess, ok := session.FromRequest(r); ok && sess.Token != "" && shortlistUserKey(r, sess.Token) == sh.OwnerKey {
+		refreshShortlistShares(sh.OwnerKey, sess.Token, sh.ShortlistID)
+		if fresh, err := shortlistShares().Get(sh.ID); err == nil {
+			sh = fresh
+		}
+	}
+
+	page := parsePositiveInt(r.URL.Query().Get("page"), 1)
+	list := sharedShortlistPage(sh, page, 9)
+
+	w.Header().Set("Content-Type", "text/html")
+	render(w, r, "pages/shared-shortlist.html", "shared-shortlist.html", map[string]any{
+		"ActivePage":      "search",
+		"List":            list,
+		"Query":           url.Values{},
+		"ShowResults":     true,
+		"SharedShortlist": sh,
+		"ShareURL":        sharePath(sh.ID),
+	})
+}
+
+// loadShare verifies the signed token in the URL and returns the live share.
+func loadShare(r *http.Request) (shortlistshare.Share, int) {
+	raw, ok := session.Unsign(chi.URLParam(r, "token"))
+	if !ok || !strings.HasPrefix(raw, shareTokenPrefix) {
+		return shortlistshare.Share{}, http.StatusNotFound
+	}
+	sh, err := shortlistShares().Get(strings.TrimPrefix(raw, shareTokenPrefix))
+	if err != nil {
+		return shortlistshare.Share{}, http.StatusNotFound
+	}
+	if err := sh.Active(time.Now()); err != nil {
+		return shortlistshare.Share{}, http.StatusGone
+	}
+	return sh, http.StatusOK
+}
+
+func sharedShortlistPage(sh shortlistshare.Share, page, limit int) api.PropertyList {
+	total := len(sh.AssetIDs)
+	pages := int(math.Ceil(float64(total) / float64(limit)))
+	if pages == 0 {
+		pages = 1
+	}
+	if page > pages {
+		page = pages
+	}
+	start := (page - 1) * limit
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	client := api.New()
+	items := make([]api.Property, 0, end-start)
+	for _, id := range sh.AssetIDs[start:end] {
+		prop, err := client.GetProperty(id)
+		if err != nil {
+			log.Printf("shared shortlist %s: skipping %s: %v", sh.ID, id, err)
+			continue
+		}
+		items = append(items, prop)
+	}
+
+	return api.PropertyList{
+		Items: items,
+		Page:  page,
+		Pages: pages,
+		Total: total,
+	}
+}
+
+func sharedShortlistUnavailable(w http.ResponseWriter, status int) {
+	msg := "This shortlist link is not valid."
+	if status == http.StatusGone {
+		msg = "This shortlist link has expired or was turned off by its owner."
+	}
+	http.Error(w, msg, status)
+}
+
+// syncShortlistShares mirrors an owner's shortlist change onto their live share links.
+func syncShortlistShares(r *http.Request, token, shortlistID, assetID string, present bool) {
+	if token == "" || shortlistID == "" || assetID == "" {
+		return
+	}
+	shortlistShares().SyncOwnerItem(shortlistUserKey(r, token), shortlistID, assetID, present)
+}
+
+// refreshShortlistShares re-reads the owner's list from Nestlo into its live share links,
+// picking up changes made outside this site.
+func refreshShortlistShares(ownerKey, token, shortlistID string) {
+	ids, err := shortlistAssetIDs(api.New(), token, shortlistID)
+	if err != nil {
+		log.Printf("shortlist share: refresh %s: %v", shortlistID, err)
+		return
+	}
+	shortlistShares().Refresh(ownerKey, shortlistID, ids)
+}
+
+func shortlistAssetIDs(client *api.Client, token, shortlistID string) ([]string, error) {
+	items, err := client.ListAllShortlistItems(token, shortlistID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(items))
+	for _, p := range items {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
+	"log"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -23,11 +27,16 @@ type shortlistAddPayload struct {
 	AssetIDAlt string `json:"asset_id"`
 }
 
+// shortlistToken returns the caller's Nestlo token from the Authorization header,
+// falling back to the cookie session.
 func shortlistToken(r *http.Request) string {
 	auth := strings.TrimSpace(r.Header.Get("Authorization"))
 	if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
 		return strings.TrimSpace(auth[7:])
 	}
+	if sess, ok := session.FromRequest(r); ok {
+		return sess.Token
+	}
 	return ""
 }
 
@@ -50,13 +59,9 @@ func isUnauthorized(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "unauthorized")
 }
 
-// ShortlistStatuses handles bulk shortlist checks for the current user.
+// ShortlistStatuses handles bulk shortlist checks for the current user or guest.
 func ShortlistStatuses(w http.ResponseWriter, r *http.Request) {
 	token := shortlistToken(r)
-	if token == "" {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
-		return
-	}
 
 	defer r.Body.Close()
 	var payload shortlistStatusRequest
@@ -74,23 +79,40 @@ func ShortlistStatuses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client := api.New()
-	statuses := make([]api.ShortlistStatus, 0, len(ids))
-	for _, id := range ids {
-		id = strings.TrimSpace(id)
-		if id == "" {
-			continue
+	if token == "" {
+		saved := make(map[string]bool)
+		for _, id := range readGuestShortlist(r) {
+			saved[id] = true
 		}
-		status, err := client.CheckShortlist(id, token)
-		if err != nil {
-			if isUnauthorized(err) {
-				http.Error(w, "authentication required", http.StatusUnauthorized)
-				return
+		statuses := make([]api.ShortlistStatus, 0, len(ids))
+		for _, id := range ids {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
 			}
-			http.Error(w, "unable to check shortlist right now", http.StatusBadGateway)
+			statuses = append(statuses, api.ShortlistStatus{AssetID: id, IsShortlisted: saved[id]})
+		}
+		writeJSON(w, map[string]any{
+			"statuses": statuses,
+			"guest":    true,
+		})
+		return
+	}
+
+	batch, err := api.New().CheckShortlistBatch(token, ids)
+	if err != nil {
+		if isUnauthorized(err) {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
 			return
 		}
-		statuses = append(statuses, status)
+		http.Error(w, "unable to check shortlist right now", http.StatusBadGateway)
+		return
+	}
+	statuses := make([]api.ShortlistStatus, 0, len(batch))
+	for _, id := range ids {
+		if status, ok := batch[strings.TrimSpace(id)]; ok {
+			statuses = append(statuses, status)
+		}
 	}
 
 	writeJSON(w, map[string]any{
@@ -98,14 +120,45 @@ func ShortlistStatuses(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// AddShortlistItem adds a property to the user's shortlist.
-func AddShortlistItem(w http.ResponseWriter, r *http.Request) {
+// annotateShortlisted marks items the current visitor has shortlisted so hearts render
+// filled on first paint. Lookup failures are logged and leave the items unmarked.
+func annotateShortlisted(r *http.Request, items []api.Property) {
+	if len(items) == 0 {
+		return
+	}
 	token := shortlistToken(r)
 	if token == "" {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
+		saved := make(map[string]bool)
+		for _, id := range readGuestShortlist(r) {
+			saved[id] = true
+		}
+		for i := range items {
+			items[i].IsShortlisted = saved[items[i].ID]
+		}
 		return
 	}
 
+	ids := make([]string, 0, len(items))
+	for _, p := range items {
+		ids = append(ids, p.ID)
+	}
+	batch, err := api.New().CheckShortlistBatch(token, ids)
+	if err != nil {
+		log.Printf("shortlist annotate: %v", err)
+		return
+	}
+	for i := range items {
+		if status, ok := batch[items[i].ID]; ok {
+			items[i].IsShortlisted = status.IsShortlisted
+			items[i].ShortlistID = status.ShortlistID
+		}
+	}
+}
+
+// AddShortlistItem adds a property to the user's shortlist, or to the guest cookie when logged out.
+func AddShortlistItem(w http.ResponseWriter, r *http.Request) {
+	token := shortlistToken(r)
+
 	defer r.Body.Close()
 	var payload shortlistAddPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
@@ -122,6 +175,20 @@ func AddShortlistItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if token == "" {
+		if !addGuestShortlist(w, r, assetID) {
+			http.Error(w, fmt.Sprintf("you can save up to %d properties before logging in", guestShortlistMax()), http.StatusConflict)
+			return
+		}
+		writeJSON(w, map[string]any{
+			"assetId":       assetID,
+			"shortlisted":   true,
+			"isShortlisted": true,
+			"guest":         true,
+		})
+		return
+	}
+
 	client := api.New()
 	status, err := client.AddToShortlist(assetID, token)
 	if err != nil {
@@ -133,6 +200,8 @@ func AddShortlistItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	syncShortlistShares(r, token, status.ShortlistID, assetID, status.IsShortlisted)
+
 	writeJSON(w, map[string]any{
 		"assetId":       status.AssetID,
 		"shortlistId":   status.ShortlistID,
@@ -141,13 +210,9 @@ func AddShortlistItem(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// RemoveShortlistItem removes a property from the user's shortlist.
+// RemoveShortlistItem removes a property from the user's (or guest's) shortlist.
 func RemoveShortlistItem(w http.ResponseWriter, r *http.Request) {
 	token := shortlistToken(r)
-	if token == "" {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
-		return
-	}
 
 	assetID := strings.TrimSpace(chi.URLParam(r, "assetID"))
 	if assetID == "" {
@@ -155,6 +220,17 @@ func RemoveShortlistItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if token == "" {
+		removeGuestShortlist(w, r, assetID)
+		writeJSON(w, map[string]any{
+			"assetId":       assetID,
+			"shortlisted":   false,
+			"isShortlisted": false,
+			"guest":         true,
+		})
+		return
+	}
+
 	client := api.New()
 	status, err := client.RemoveFromShortlist(assetID, token)
 	if err != nil {
@@ -166,6 +242,8 @@ func RemoveShortlistItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	syncShortlistShares(r, token, status.ShortlistID, assetID, status.IsShortlisted)
+
 	writeJSON(w, map[string]any{
 		"assetId":       status.AssetID,
 		"shortlistId":   status.ShortlistID,
@@ -174,28 +252,79 @@ func RemoveShortlistItem(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// ShortlistResultsView renders the shortlist results list for the authenticated user.
+// ShortlistResultsView renders the shortlist results list for the authenticated user or guest.
 func ShortlistResultsView(w http.ResponseWriter, r *http.Request) {
 	token := shortlistToken(r)
-	if token == "" {
-		http.Error(w, "authentication required", http.StatusUnauthorized)
-		return
-	}
 
 	page := parsePositiveInt(r.URL.Query().Get("page"), 1)
 	limit := parsePositiveInt(r.URL.Query().Get("limit"), 9)
 
+	if token == "" {
+		renderShortlistPartial(w, guestShortlistPage(r, page, limit), map[string]any{
+			"ShortlistGuest": true,
+		})
+		return
+	}
+
 	client := api.New()
-	list, err := client.ListShortlisted(token, page, limit)
+	lists, err := client.ListShortlists(token)
 	if err != nil {
 		if isUnauthorized(err) {
 			http.Error(w, "authentication required", http.StatusUnauthorized)
 			return
 		}
-		http.Error(w, "unable to load shortlist", http.StatusBadGateway)
+		log.Printf("shortlist view: could not load lists: %v", err)
+	}
+
+	activeID := strings.TrimSpace(r.URL.Query().Get("list"))
+	filter := parseShortlistFilter(r)
+	var list api.PropertyList
+	var tags []string
+	if filter.active() {
+		// Filtering and sorting need the whole list; Nestlo only pages in added order.
+		var all []api.Property
+		all, err = client.ListAllShortlistItems(token, activeID)
+		if err == nil {
+			applyShortlistMeta(r, token, all)
+			tags = shortlistTags(all)
+			list = filterShortlist(all, filter, page, limit)
+		}
+	} else if activeID != "" {
+		list, err = client.ListShortlistItems(token, activeID, page, limit)
+	} else {
+		list, err = client.ListShortlisted(token, page, limit)
+	}
+	if err != nil {
+		writeShortlistError(w, err, "unable to load shortlist")
 		return
 	}
+	if !filter.active() {
+		applyShortlistMeta(r, token, list.Items)
+		tags = shortlistTags(list.Items)
+	}
 
+	active := api.Shortlist{}
+	for _, l := range lists {
+		if (activeID == "" && l.IsDefault) || l.ID == activeID {
+			active = l
+			break
+		}
+	}
+	if active.ID == "" && activeID == "" && len(lists) > 0 {
+		active = lists[0]
+	}
+
+	renderShortlistPartial(w, list, map[string]any{
+		"Shortlists":      lists,
+		"ActiveShortlist": active,
+		"ShortlistFilter": filter,
+		"ShortlistTags":   tags,
+		"ShortlistMeta":   true,
+	})
+}
+
+// renderShortlistPartial renders the search-results-list partial in shortlist mode.
+func renderShortlistPartial(w http.ResponseWriter, list api.PropertyList, extra map[string]any) {
 	w.Header().Set("Content-Type", "text/html")
 
 	templates := []string{
@@ -212,6 +341,7 @@ func ShortlistResultsView(w http.ResponseWriter, r *http.Request) {
 		"sub":         sub,
 		"seq":         seq,
 		"dict":        dict,
+		"join":        strings.Join,
 	}
 
 	t := template.Must(template.New("shortlist-partial").Funcs(funcs).ParseFiles(templates...))
@@ -223,10 +353,19 @@ func ShortlistResultsView(w http.ResponseWriter, r *http.Request) {
 		"ShowResults":      true,
 		"ShortlistEnabled": true,
 		"ShortlistMode":    true,
+		// guests and failed list lookups have no named lists
+		"Shortlists": []api.Shortlist{},
+	}
+	for k, v := range extra {
+		data[k] = v
 	}
 
-	if err := t.ExecuteTemplate(w, "partials/search-results-list.html", data); err != nil {
+	// render into a buffer so a template error does not leave half a partial behind
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "partials/search-results-list.html", data); err != nil {
+		log.Printf("shortlist view: template error: %v", err)
 		http.Error(w, "template error", http.StatusInternalServerError)
 		return
 	}
+	_, _ = buf.WriteTo(w)
 }
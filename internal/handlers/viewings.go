@@ -0,0 +1,553 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/leadoutbox"
+	"github.com/BohoBytes/dhakahome-web/internal/mailer"
+	"github.com/BohoBytes/dhakahome-web/internal/notify"
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+	"github.com/BohoBytes/dhakahome-web/internal/viewing"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	viewingTokenPrefix = "viewing:"
+	viewingJobNotice   = "viewing_notice" // customer confirmation with .ics
+)
+
+var (
+	viewingOnce     sync.Once
+	viewingAvail    *viewing.FileAvailability
+	viewingBookings viewing.Store
+	viewingNotifier notify.Notifier
+)
+
+func viewings() (*viewing.FileAvailability, viewing.Store) {
+	viewingOnce.Do(func() {
+		viewingAvail = viewing.NewAvailabilityFromEnv()
+		viewingBookings = viewing.NewStoreFromEnv()
+		viewingNotifier = notify.NewFromEnv()
+	})
+	return viewingAvail, viewingBookings
+}
+
+// sendViewingNotice is the outbox handler for viewingJobNotice jobs.
+func sendViewingNotice(j leadoutbox.Job) error {
+	var m notify.Message
+	if err := json.Unmarshal(j.Payload, &m); err != nil {
+		return leadoutbox.Permanent(err)
+	}
+	viewings()
+	return viewingNotifier.Send(m)
+}
+
+type viewingDay struct {
+	Date  string        `json:"date"`
+	Label string        `json:"label"`
+	Slots []viewingSlot `json:"slots"`
+}
+
+type viewingSlot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	AgentID   string    `json:"agentId"`
+	AgentName string    `json:"agentName,omitempty"`
+	Label     string    `json:"label"`
+}
+
+// openViewingSlots lists bookable slots for a property from now on, minus taken ones.
+func openViewingSlots(assetID string) ([]viewing.Slot, *time.Location, error) {
+	avail, store := viewings()
+	sched, err := avail.Schedule()
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(firstNonEmpty(sched.Timezone, "Asia/Dhaka"))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	to := now.AddDate(0, 0, 60)
+	slots, err := sched.Slots(assetID, now, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return viewing.FreeSlots(slots, store.Taken(now, to)), loc, nil
+}
+
+// groupViewingSlots groups slots by local day. When several agents are free at the same
+// time only the first is offered; the visitor picks a time, not an agent.
+func groupViewingSlots(slots []viewing.Slot, loc *time.Location) []viewingDay {
+	var days []viewingDay
+	seen := map[int64]bool{}
+	for _, s := range slots {
+		if seen[s.Start.Unix()] {
+			continue
+		}
+		seen[s.Start.Unix()] = true
+		local := s.Start.In(loc)
+		date := local.Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, viewingDay{Date: date, Label: local.Format("Mon 2 Jan")})
+		}
+		days[len(days)-1].Slots = append(days[len(days)-1].Slots, viewingSlot{
+			Start:     s.Start,
+			End:       s.End,
+			AgentID:   s.AgentID,
+			AgentName: s.AgentName,
+			Label:     local.Format("3:04 PM"),
+		})
+	}
+	return days
+}
+
+// ViewingSlots handles GET /api/properties/{id}/viewing-slots.
+func ViewingSlots(w http.ResponseWriter, r *http.Request) {
+	assetID := strings.TrimSpace(chi.URLParam(r, "id"))
+	slots, loc, err := openViewingSlots(assetID)
+	if err != nil {
+		log.Printf("viewing slots: %v", err)
+		http.Error(w, "viewing times are unavailable right now", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, map[string]any{"timezone": loc.String(), "days": groupViewingSlots(slots, loc)})
+}
+
+type viewingPayload struct {
+	Start        string `json:"start"` // RFC 3339, from the slot list
+	AgentID      string `json:"agentId"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Phone        string `json:"phone"`
+	Notes        string `json:"notes"`
+	Website      string `json:"website"`
+	FormToken    string `json:"formToken"`
+	CaptchaToken string `json:"captchaToken"`
+}
+
+// findViewingSlot matches a requested start (and optional agent) against the open slots.
+func findViewingSlot(assetID, start, agentID string) (viewing.Slot, bool, error) {
+	at, err := time.Parse(time.RFC3339, strings.TrimSpace(start))
+	if err != nil {
+		return viewing.Slot{}, false, nil
+	}
+	slots, _, err := openViewingSlots(assetID)
+	if err != nil {
+		return viewing.Slot{}, false, err
+	}
+	var match viewing.Slot
+	found := false
+	for _, s := range slots {
+		if !s.Start.Equal(at) {
+			continue
+		}
+		if s.AgentID == agentID {
+			return s, true, nil
+		}
+		if !found {
+			match, found = s, true
+		}
+	}
+	return match, found, nil
+}
+
+// BookViewing handles POST /api/properties/{id}/viewings.
+func BookViewing(w http.ResponseWriter, r *http.Request) {
+	assetID := strings.TrimSpace(chi.URLParam(r, "id"))
+	defer r.Body.Close()
+	var in viewingPayload
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&in); err != nil {
+		writeLeadJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid request body"})
+		return
+	}
+
+	lead, errs := validateLead(leadPayload{Name: in.Name, Email: in.Email, Phone: in.Phone, Message: in.Notes, PropertyID: assetID})
+	delete(errs, "message") // notes are optional for a viewing
+	slot, ok, err := findViewingSlot(assetID, in.Start, in.AgentID)
+	if err != nil {
+		log.Printf("viewing slots: %v", err)
+		writeLeadJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "viewing times are unavailable right now"})
+		return
+	}
+	if !ok {
+		errs["start"] = "That time is no longer available, please pick another."
+	}
+	if len(errs) > 0 {
+		writeLeadJSON(w, http.StatusBadRequest, map[string]any{"errors": errs})
+		return
+	}
+
+	verdict, captchaOK := screenLead(r, leadPayload{
+		Name: lead.Name, Email: lead.Email, Message: strings.TrimSpace(in.Notes),
+		Website: in.Website, FormToken: in.FormToken, CaptchaToken: in.CaptchaToken,
+	})
+	if !captchaOK {
+		writeLeadJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": map[string]string{"captcha": "Please complete the captcha and try again."},
+		})
+		return
+	}
+
+	prop, found, err := api.New().LookupProperty(assetID)
+	if err != nil || !found {
+		if err != nil {
+			log.Printf("viewing: property %s: %v", assetID, err)
+		}
+		writeLeadJSON(w, http.StatusNotFound, map[string]any{"error": "property not found"})
+		return
+	}
+
+	booking := viewing.Booking{
+		AssetID:       assetID,
+		PropertyTitle: firstNonEmpty(prop.Title, "Property "+assetID),
+		Address:       prop.Address,
+		AgentID:       slot.AgentID,
+		AgentName:     slot.AgentName,
+		Start:         slot.Start,
+		End:           slot.End,
+		Name:          lead.Name,
+		Email:         lead.Email,
+		Phone:         lead.Phone,
+		Notes:         strings.TrimSpace(in.Notes),
+	}
+	if verdict.Score >= leadSpamThreshold() {
+		// Do not hold a real slot for a bot; answer as if it worked.
+		quarantineLead(r, "viewing", verdict, booking)
+		writeLeadJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+		return
+	}
+
+	_, store := viewings()
+	booking, err = store.Create(booking)
+	if errors.Is(err, viewing.ErrSlotTaken) {
+		writeLeadJSON(w, http.StatusConflict, map[string]any{
+			"errors": map[string]string{"start": "That time was just booked, please pick another."},
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("viewing: create booking: %v", err)
+		writeLeadJSON(w, http.StatusServiceUnavailable, map[string]any{"error": "could not book the viewing, please try again"})
+		return
+	}
+
+	contactEmail, _ := propertyContact(prop)
+	req := api.LeadReq{
+		Name:         booking.Name,
+		Email:        booking.Email,
+		Phone:        booking.Phone,
+		PropertyID:   assetID,
+		Message:      viewingLeadNote("Viewing request", booking),
+		ContactEmail: contactEmail,
+	}
+	nestlo := api.NestloLeadPayload{
+		LeadType: deriveLeadType(prop.ListingType),
+		Source:   "web",
+		ClientInfo: api.NestloLeadClientInfo{
+			Name:                   booking.Name,
+			Email:                  booking.Email,
+			Phone:                  booking.Phone,
+			PreferredContactMethod: "in_person",
+		},
+		Notes:   viewingLeadNote("[Viewing request]", booking),
+		AssetID: assetID,
+	}
+	attributeLead(r, &req, &nestlo)
+	assigned := routeLead(propertySubject(prop), &req, &nestlo)
+	// The agent hosting the viewing hears about it even when the lead is routed elsewhere.
+	alertTo := assigned
+	if email := viewingAgentEmail(booking.AgentID); email != "" {
+		req.ContactEmail = email
+		alertTo.Email = email
+	}
+
+	leadID, err := queueLead(&req, &nestlo)
+	if err != nil {
+		// The slot is held; staff still see the booking in the store, so do not fail the visitor.
+		log.Printf("viewing %s: lead enqueue failed: %v", booking.ID, err)
+	} else {
+		recordLeadAttribution(r, leadID, "viewing")
+		if err := store.SetLeadID(booking.ID, leadID); err != nil {
+			log.Printf("viewing %s: %v", booking.ID, err)
+		}
+		// The visitor's confirmation is the calendar invite below, so staff only.
+		mail := newMailLead(r, leadID, "viewing")
+		mail.Name, mail.Email, mail.Phone, mail.Message = booking.Name, booking.Email, booking.Phone, booking.Notes
+		mail.PropertyID, mail.PropertyTitle = assetID, booking.PropertyTitle
+		mail.PropertyURL = siteURL(r) + "/properties/" + assetID
+		when := booking.Start.In(viewingLocation()).Format("Monday 2 January, 3:04 PM")
+		if booking.AgentName != "" {
+			when += " with " + booking.AgentName
+		}
+		mail.Details = []mailer.Detail{{Label: "Viewing", Value: when}}
+		mail.AssignedTo = assigned.Label()
+		queueLeadMail(mail, alertTo, false)
+	}
+	queueViewingNotice(r, booking, "Your viewing is booked")
+
+	writeLeadJSON(w, http.StatusOK, map[string]any{
+		"status":    "ok",
+		"reference": booking.ID,
+		"start":     booking.Start,
+		"manageUrl": viewingManagePath(booking.ID),
+		"icsUrl":    viewingManagePath(booking.ID) + "/invite.ics",
+	})
+}
+
+// ViewingPage handles GET /viewings/{token}: booking details with reschedule and cancel.
+func ViewingPage(w http.ResponseWriter, r *http.Request) {
+	booking, ok := viewingFromToken(w, r)
+	if !ok {
+		return
+	}
+	var days []viewingDay
+	if booking.Active() {
+		if slots, loc, err := openViewingSlots(booking.AssetID); err == nil {
+			days = groupViewingSlots(slots, loc)
+		} else {
+			log.Printf("viewing slots: %v", err)
+		}
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-store")
+	render(w, r, "pages/viewing.html", "viewing.html", map[string]any{
+		"Booking":   booking,
+		"When":      booking.Start.In(viewingLocation()).Format("Monday 2 January 2006, 3:04 PM"),
+		"Days":      days,
+		"ManageURL": viewingManagePath(booking.ID),
+		"Updated":   r.URL.Query().Get("updated"),
+	})
+}
+
+// RescheduleViewing handles POST /viewings/{token}/reschedule with a new slot start.
+func RescheduleViewing(w http.ResponseWriter, r *http.Request) {
+	booking, ok := viewingFromToken(w, r)
+	if !ok {
+		return
+	}
+	start, agentID := r.FormValue("start"), r.FormValue("agentId")
+	if strings.Contains(strings.ToLower(r.Header.Get("Content-Type")), "application/json") {
+		var in viewingPayload
+		_ = json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&in)
+		start, agentID = in.Start, in.AgentID
+	}
+
+	slot, found, err := findViewingSlot(booking.AssetID, start, agentID)
+	if err == nil && !found {
+		err = viewing.ErrSlotTaken
+	}
+	if err == nil {
+		_, store := viewings()
+		booking, err = store.Reschedule(booking.ID, slot)
+	}
+	if err != nil {
+		viewingActionError(w, r, booking, err)
+		return
+	}
+
+	queueViewingChange(r, booking, "Viewing rescheduled")
+	queueViewingNotice(r, booking, "Your viewing has been moved")
+	viewingActionDone(w, r, booking, "rescheduled")
+}
+
+// CancelViewing handles POST /viewings/{token}/cancel.
+func CancelViewing(w http.ResponseWriter, r *http.Request) {
+	booking, ok := viewingFromToken(w, r)
+	if !ok {
+		return
+	}
+	_, store := viewings()
+	booking, err := store.Cancel(booking.ID)
+	if err != nil {
+		viewingActionError(w, r, booking, err)
+		return
+	}
+	queueViewingChange(r, booking, "Viewing cancelled")
+	queueViewingNotice(r, booking, "Your viewing has been cancelled")
+	viewingActionDone(w, r, booking, "cancelled")
+}
+
+// ViewingInvite handles GET /viewings/{token}/invite.ics.
+func ViewingInvite(w http.ResponseWriter, r *http.Request) {
+	booking, ok := viewingFromToken(w, r)
+	if !ok {
+		return
+	}
+	inv := viewingInvite(r, booking)
+	w.Header().Set("Content-Type", inv.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="dhakahome-viewing.ics"`)
+	_, _ = w.Write(inv.ICS())
+}
+
+func viewingFromToken(w http.ResponseWriter, r *http.Request) (viewing.Booking, bool) {
+	raw, ok := session.Unsign(chi.URLParam(r, "token"))
+	if !ok || !strings.HasPrefix(raw, viewingTokenPrefix) {
+		http.NotFound(w, r)
+		return viewing.Booking{}, false
+	}
+	_, store := viewings()
+	booking, err := store.Get(strings.TrimPrefix(raw, viewingTokenPrefix))
+	if err != nil {
+		http.NotFound(w, r)
+		return viewing.Booking{}, false
+	}
+	return booking, true
+}
+
+func viewingManagePath(bookingID string) string {
+	return "/viewings/" + url.PathEscape(session.Sign(viewingTokenPrefix+bookingID))
+}
+
+func viewingActionDone(w http.ResponseWriter, r *http.Request, b viewing.Booking, what string) {
+	if wantsJSON(r) {
+		writeJSON(w, map[string]any{"status": b.Status, "start": b.Start, "updated": what})
+		return
+	}
+	http.Redirect(w, r, viewingManagePath(b.ID)+"?updated="+what, http.StatusSeeOther)
+}
+
+func viewingActionError(w http.ResponseWriter, r *http.Request, b viewing.Booking, err error) {
+	status, msg := http.StatusServiceUnavailable, "Could not update the viewing, please try again."
+	switch {
+	case errors.Is(err, viewing.ErrSlotTaken):
+		status, msg = http.StatusConflict, "That time is no longer available, please pick another."
+	case errors.Is(err, viewing.ErrCancelled):
+		status, msg = http.StatusConflict, "This viewing has already been cancelled."
+	default:
+		log.Printf("viewing %s: %v", b.ID, err)
+	}
+	if wantsJSON(r) {
+		writeLeadJSON(w, status, map[string]any{"error": msg})
+		return
+	}
+	http.Error(w, msg, status)
+}
+
+func viewingInvite(r *http.Request, b viewing.Booking) viewing.Invite {
+	site := siteURL(r)
+	return viewing.Invite{
+		Booking:       b,
+		OrganizerName: "DhakaHome",
+		OrganizerMail: defaultContactEmail(),
+		ManageURL:     site + viewingManagePath(b.ID),
+		PropertyURL:   site + "/properties/" + url.PathEscape(b.AssetID),
+	}
+}
+
+// queueViewingNotice emails the visitor the current state of the booking with an
+// updated invite. Delivery goes through the lead outbox so it is retried.
+func queueViewingNotice(r *http.Request, b viewing.Booking, subject string) {
+	inv := viewingInvite(r, b)
+	when := b.Start.In(viewingLocation()).Format("Monday 2 January, 3:04 PM")
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Hi %s,\n\n", b.Name)
+	if b.Active() {
+		fmt.Fprintf(&text, "Your viewing of %s is booked for %s", b.PropertyTitle, when)
+		if b.AgentName != "" {
+			fmt.Fprintf(&text, " with %s", b.AgentName)
+		}
+		text.WriteString(".\n")
+		if b.Address != "" {
+			fmt.Fprintf(&text, "Address: %s\n", b.Address)
+		}
+		fmt.Fprintf(&text, "\nThe attached invite adds it to your calendar.\nNeed another time? Reschedule or cancel here: %s\n", inv.ManageURL)
+	} else {
+		fmt.Fprintf(&text, "Your viewing of %s on %s has been cancelled.\n", b.PropertyTitle, when)
+		fmt.Fprintf(&text, "Book another time from the listing: %s\n", inv.PropertyURL)
+	}
+	text.WriteString("\nDhakaHome\n")
+
+	msg := notify.Message{
+		Channel: notify.ChannelEmail,
+		To:      b.Email,
+		Subject: "DhakaHome: " + subject,
+		Text:    text.String(),
+		Attachments: []notify.Attachment{{
+			Name:        "dhakahome-viewing.ics",
+			ContentType: inv.ContentType(),
+			Data:        inv.ICS(),
+		}},
+	}
+	job, err := leadoutbox.NewJob(leadoutbox.NewLeadID(), viewingJobNotice, msg)
+	if err == nil {
+		err = leadOutbox().Store.Enqueue(job)
+	}
+	if err != nil {
+		log.Printf("viewing %s: queue notice: %v", b.ID, err)
+		return
+	}
+	leadOutbox().Kick()
+}
+
+// queueViewingChange tells the agent about a reschedule or cancellation.
+func queueViewingChange(r *http.Request, b viewing.Booking, what string) {
+	req := api.LeadReq{
+		Name:         b.Name,
+		Email:        b.Email,
+		Phone:        b.Phone,
+		PropertyID:   b.AssetID,
+		Message:      viewingLeadNote(what, b),
+		ContactEmail: firstNonEmpty(viewingAgentEmail(b.AgentID), defaultContactEmail()),
+	}
+	if _, err := queueLead(&req, nil); err != nil {
+		log.Printf("viewing %s: queue agent update: %v", b.ID, err)
+	}
+}
+
+func viewingLeadNote(prefix string, b viewing.Booking) string {
+	note := fmt.Sprintf("%s: %s", prefix, b.Start.In(viewingLocation()).Format("Mon 2 Jan 2006 3:04 PM"))
+	if b.AgentName != "" {
+		note += " with " + b.AgentName
+	}
+	note += " (booking " + b.ID + ")."
+	if b.Notes != "" {
+		note += "\n\n" + b.Notes
+	}
+	return note
+}
+
+func viewingAgentEmail(agentID string) string {
+	avail, _ := viewings()
+	ag, ok := avail.Agent(agentID)
+	if !ok {
+		return ""
+	}
+	return ag.Email
+}
+
+func viewingLocation() *time.Location {
+	avail, _ := viewings()
+	sched, _ := avail.Schedule()
+	if loc, err := time.LoadLocation(firstNonEmpty(sched.Timezone, "Asia/Dhaka")); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// siteURL is PUBLIC_SITE_URL for absolute links in emails, invites and exports. Only a
+// local run falls back to the request's origin: elsewhere the Host header is not trusted
+// and links stay site-relative until PUBLIC_SITE_URL is set.
+func siteURL(r *http.Request) string {
+	cfg := config.Get()
+	if cfg.PublicSiteURL != "" || !cfg.IsLocal() {
+		return cfg.PublicSiteURL
+	}
+	scheme := "http"
+	if session.IsSecure(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/attribution"
+	"github.com/BohoBytes/dhakahome-web/internal/leadoutbox"
+)
+
+// WhatsAppChat handles GET /whatsapp/{id}. It logs the click as a lead event for the
+// lead-source report and redirects to WhatsApp click-to-chat with the routed agent's
+// number and a message naming the listing. ?from= says which button was used.
+func WhatsAppChat(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	p, found, err := api.New().LookupProperty(id)
+	if err != nil {
+		log.Printf("whatsapp: property %s: %v", id, err)
+	}
+	if !found {
+		p = api.Property{}
+	}
+
+	if !isCrawler(r) {
+		rec := attribution.NewRecord(leadoutbox.NewLeadID(), "whatsapp", attribution.FromRequest(r))
+		rec.PropertyID = id
+		rec.Placement = whatsAppPlacement(r.URL.Query().Get("from"))
+		if err := leadAttribution().Append(rec); err != nil {
+			log.Printf("whatsapp: record click: %v", err)
+		}
+	}
+
+	text := "Hi DhakaHome, I'm interested in one of your listings."
+	if p.ID != "" {
+		text = "Hi DhakaHome, I'm interested in " + p.Title + " (" + siteURL(r) + "/properties/" + p.ID + "). Is it still available?"
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	http.Redirect(w, r, whatsAppURL(propertyWhatsApp(p), text), http.StatusFound)
+}
+
+// whatsAppURL builds a wa.me click-to-chat link; the number must be digits only,
+// including the country code.
+func whatsAppURL(number, text string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+	return "https://wa.me/" + digits + "?text=" + strings.ReplaceAll(url.QueryEscape(text), "+", "%20")
+}
+
+func whatsAppPlacement(from string) string {
+	switch from := strings.ToLower(strings.TrimSpace(from)); from {
+	case "property", "card", "similar", "shortlist":
+		return from
+	}
+	return "other"
+}
+
+// isCrawler skips link checkers and search bots so they do not count as enquiries.
+func isCrawler(r *http.Request) bool {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return true
+	}
+	for _, marker := range []string{"bot", "crawl", "spider", "slurp", "preview", "facebookexternalhit", "curl/", "wget"} {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
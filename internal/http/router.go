@@ -2,24 +2,37 @@ package httpx
 
 import (
 	"net/http"
-	"os"
+	"time"
 
+	"github.com/BohoBytes/dhakahome-web/internal/attribution"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/csrf"
 	"github.com/BohoBytes/dhakahome-web/internal/handlers"
+	"github.com/BohoBytes/dhakahome-web/internal/mw"
+	"github.com/BohoBytes/dhakahome-web/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 )
 
 func NewRouter() *chi.Mux {
 	r := chi.NewMux()
 
-	// Temporarily disable middleware for debugging
-	// r.Use(mw.RequestLogger())
-	// r.Use(cors.Handler(cors.Options{
-	//     AllowedOrigins:   []string{"*"}, // dev only; restrict in prod
-	//     AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-	//     AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-	//     AllowCredentials: false,
-	//     MaxAge:           300,
-	// }))
+	cfg := config.Get()
+
+	r.Use(mw.RequestID)
+	r.Use(mw.RealIP(cfg.HTTP.TrustProxyHeaders))
+	r.Use(mw.RequestLogger(cfg.Environment))
+	// before the recoverer, so the error page gets a nonce too
+	r.Use(mw.SecurityHeaders(mw.SecurityOptions{
+		CSP:        cfg.HTTP.CSPMode,
+		ReportURI:  "/csp-report",
+		HSTSMaxAge: cfg.HTTP.HSTSMaxAge, // only sent over HTTPS
+	}))
+	r.Use(mw.Recoverer(handlers.ServerError))
+	r.Use(mw.Compress())
+	r.Use(mw.CORS(mw.CORSOrigins(cfg.Environment, cfg.HTTP.CORSAllowedOrigins, cfg.PublicSiteURL)))
+	r.Use(mw.BodyLimit(cfg.HTTP.MaxBodyBytes))
+	r.Use(attribution.Middleware)
+	r.Use(csrf.Protect("/csp-report"))
 
 	// static assets
 	r.Handle("/assets/*", http.StripPrefix("/assets/", http.FileServer(http.Dir("public/assets"))))
@@ -33,6 +46,19 @@ func NewRouter() *chi.Mux {
 	r.Handle("/apple-touch-icon.png", publicFS)
 	r.Handle("/robots.txt", publicFS)
 
+	// slow exports get longer than the rest of the site
+	r.With(mw.Timeout(cfg.HTTP.ExportTimeout)).Get("/api/shortlists/export.csv", handlers.ExportShortlistCSV)
+	r.With(mw.Timeout(cfg.HTTP.ExportTimeout)).Get("/api/shortlists/export.pdf", handlers.ExportShortlistPDF)
+
+	r.Group(func(r chi.Router) {
+		r.Use(mw.Timeout(cfg.HTTP.RouteTimeout))
+		routes(r)
+	})
+
+	return r
+}
+
+func routes(r chi.Router) {
 	// pages
 	r.Get("/", handlers.Home)
 	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +75,9 @@ func NewRouter() *chi.Mux {
 	r.Get("/contact-us", handlers.ContactUsPage)
 	r.Get("/contact", handlers.ContactUsPage) // alias
 	r.Get("/properties/{id}", handlers.PropertyPage)
+	r.Get("/compare", handlers.ComparePage)
+	r.Get("/recent", handlers.RecentPage)
+	r.Post("/recent/clear", handlers.ClearRecentlyViewed)
 
 	// search filter data
 	r.Get("/api/search/cities", handlers.CitiesJSON)
@@ -59,20 +88,86 @@ func NewRouter() *chi.Mux {
 	r.Post("/api/shortlists/items", handlers.AddShortlistItem)
 	r.Delete("/api/shortlists/items/{assetID}", handlers.RemoveShortlistItem)
 	r.Get("/api/shortlists/view", handlers.ShortlistResultsView)
+	r.Put("/api/shortlists/items/{assetID}/meta", handlers.UpdateShortlistMeta)
+	r.Get("/api/shortlists", handlers.ListShortlists)
+	r.Post("/api/shortlists", handlers.CreateShortlist)
+	r.Patch("/api/shortlists/{shortlistID}", handlers.RenameShortlist)
+	r.Delete("/api/shortlists/{shortlistID}", handlers.DeleteShortlist)
+	r.Post("/api/shortlists/{shortlistID}/items", handlers.AddNamedShortlistItem)
+	r.Delete("/api/shortlists/{shortlistID}/items/{assetID}", handlers.RemoveNamedShortlistItem)
+	r.Post("/api/shortlists/{shortlistID}/items/{assetID}/move", handlers.MoveShortlistItem)
+	r.Get("/api/shortlists/{shortlistID}/shares", handlers.ListShortlistShares)
+	r.Post("/api/shortlists/{shortlistID}/shares", handlers.CreateShortlistShare)
+	r.Delete("/api/shortlists/shares/{shareID}", handlers.RevokeShortlistShare)
+	r.Get("/shortlists/shared/{token}", handlers.SharedShortlistPage)
+
+	// saved searches
+	r.Get("/saved-searches", handlers.SavedSearchesPage)
+	r.Get("/api/saved-searches", handlers.ListSavedSearches)
+	r.Post("/api/saved-searches", handlers.CreateSavedSearch)
+	r.Delete("/api/saved-searches/{searchID}", handlers.DeleteSavedSearch)
+
+	// property watches
+	r.Get("/api/watches", handlers.ListPropertyWatches)
+	r.Post("/api/properties/{id}/watch", handlers.WatchProperty)
+	r.Delete("/api/properties/{id}/watch", handlers.UnwatchProperty)
+
+	// viewings
+	r.Get("/api/properties/{id}/viewing-slots", handlers.ViewingSlots)
+	r.Post("/api/properties/{id}/viewings", handlers.BookViewing)
+	r.Get("/viewings/{token}", handlers.ViewingPage)
+	r.Get("/viewings/{token}/invite.ics", handlers.ViewingInvite)
+	r.Post("/viewings/{token}/reschedule", handlers.RescheduleViewing)
+	r.Post("/viewings/{token}/cancel", handlers.CancelViewing)
+
+	// throttle form abuse; a zero limit (RATE_LIMIT_*=off) disables a rule
+	limits := ratelimit.NewMemoryStore()
+	rl := config.Get().RateLimits
+	rule := func(name string, l config.RateLimit, key ratelimit.KeyFunc) ratelimit.Rule {
+		return ratelimit.Rule{Name: name, Limit: l.Limit, Window: l.Window, Key: key}
+	}
+	leadLimit := &ratelimit.Limiter{Store: limits, Rules: []ratelimit.Rule{
+		rule("lead-ip", rl.LeadPerIP, ratelimit.ByIP),
+		rule("lead-contact", rl.LeadPerContact, ratelimit.ByFields("phone", "email")),
+	}}
+	loginLimit := &ratelimit.Limiter{Store: limits, Rules: []ratelimit.Rule{
+		rule("login-ip", rl.LoginPerIP, ratelimit.ByIP),
+		rule("login-account", rl.LoginPerAccount, ratelimit.ByFields("email")),
+	}}
+	// otp.Store throttles each phone number; this stops one IP cycling through numbers
+	otpLimit := &ratelimit.Limiter{Store: limits, Rules: []ratelimit.Rule{
+		rule("otp-ip", rl.OTPPerIP, ratelimit.ByIP),
+	}}
 
 	// htmx partials
 	// forms
-	r.Post("/api/auth/login", handlers.Login)
-	r.Post("/lead", handlers.SubmitLead)
+	r.With(loginLimit.Handler).Post("/api/auth/login", handlers.Login)
+	r.Post("/api/auth/logout", handlers.Logout)
+	r.With(otpLimit.Handler).Post("/api/auth/otp/request", handlers.RequestOTP)
+	r.With(otpLimit.Handler).Post("/api/auth/otp/verify", handlers.VerifyOTP)
+	r.With(leadLimit.Handler).Post("/lead", handlers.SubmitLead)
+	r.Get("/whatsapp/{id}", handlers.WhatsAppChat)
+	r.Get("/requirements", handlers.RequirementPage)
+	r.Post("/requirements", handlers.SubmitRequirement)
+
+	// browsers post Content-Security-Policy violations here
+	cspLimit := &ratelimit.Limiter{Store: limits, Rules: []ratelimit.Rule{
+		{Name: "csp-report", Limit: 30, Window: time.Minute, Key: ratelimit.ByIP},
+	}}
+	r.With(cspLimit.Handler).Post("/csp-report", mw.CSPReport)
+
+	// local analytics
+	r.Get("/analytics/leads", handlers.LeadSourcesPage)
+	r.Get("/analytics/outbox", handlers.LeadOutboxPage)
+	r.Post("/analytics/outbox/requeue", handlers.RequeueLeadOutboxJob)
 
 	// health
-	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	r.Get("/healthz", handlers.Healthz)
+	r.Get("/readyz", handlers.Readyz)
 
 	// debug api
 	r.Get("/debug/api", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain")
-		_, _ = w.Write([]byte(os.Getenv("API_BASE_URL")))
+		_, _ = w.Write([]byte(config.Get().API.BaseURL))
 	})
-
-	return r
 }
@@ -0,0 +1,105 @@
+// Package jsonstore is the disk half of the site's small file-backed stores: each
+// store keeps its records in a map under its own mutex and hands the map to a File
+// after every write. The whole snapshot is rewritten each time, which is fine for the
+// few thousand small records these stores hold; anything larger belongs in Nestlo.
+package jsonstore
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path resolves a *_PATH setting: empty means def, and "memory" means no file at all.
+func Path(raw, def string) string {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.EqualFold(raw, "memory"):
+		return ""
+	case raw == "":
+		return def
+	}
+	return raw
+}
+
+// File is the JSON snapshot behind one store. A File with an empty path keeps nothing
+// on disk, so in-memory stores can call Save unconditionally.
+type File struct {
+	name string
+	path string
+	lock *os.File // held open for the life of the process
+}
+
+// Open loads the snapshot at path into v and locks it against other processes. name
+// prefixes log lines. A missing file is an empty store; an unreadable file or a lock
+// held elsewhere is logged rather than returned, so a bad snapshot never keeps the
+// site from starting.
+func Open(name, path string, v any) *File {
+	f := &File{name: name, path: path}
+	if path == "" {
+		return f
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("%s: could not create %s: %v", name, filepath.Dir(path), err)
+	}
+	lockFile, err := lock(path + ".lock")
+	if err != nil {
+		log.Printf("%s: %s is in use by another process, writes from either will be lost: %v", name, path, err)
+	}
+	f.lock = lockFile
+	if err := f.load(v); err != nil {
+		log.Printf("%s: could not load %s: %v", name, path, err)
+	}
+	return f
+}
+
+func (f *File) load(v any) error {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Save replaces the snapshot with v. The new contents are written to a temporary file
+// and fsynced before the rename, and the directory is synced after it, so a crash
+// leaves either the previous snapshot or this one. Callers hold their store's mutex.
+func (f *File) Save(v any) error {
+	if f == nil || f.path == "" {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(raw); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
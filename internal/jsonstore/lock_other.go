@@ -0,0 +1,11 @@
+//go:build !unix
+
+package jsonstore
+
+import "os"
+
+// Without flock a second process on the same file goes unnoticed; deployments run on Linux.
+func lock(string) (*os.File, error) { return nil, nil }
+
+// Directories cannot be fsynced here; the rename is as durable as the platform makes it.
+func syncDir(string) error { return nil }
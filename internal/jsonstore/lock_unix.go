@@ -0,0 +1,32 @@
+//go:build unix
+
+package jsonstore
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes a non-blocking exclusive flock on path. The lock lasts as long as the
+// returned file stays open, so the caller must keep a reference to it.
+func lock(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// syncDir makes a rename inside dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
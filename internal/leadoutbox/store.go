@@ -0,0 +1,243 @@
+// Package leadoutbox stores outgoing leads on disk before anyone is told they were sent,
+// then delivers them in the background with retries, backoff and dead-lettering.
+package leadoutbox
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+var (
+	ErrNotFound = errors.New("leadoutbox: job not found")
+	ErrNotDead  = errors.New("leadoutbox: only dead jobs can be requeued")
+)
+
+// Job is one delivery to one endpoint. Its ID is sent as an Idempotency-Key header for
+// Nestlo's logs. Handlers retry only failures that cannot have created the lead and
+// dead-letter the rest, so only an operator requeues a delivery that may have landed.
+type Job struct {
+	ID            string          `json:"id"`
+	LeadID        string          `json:"leadId"` // groups the jobs of one submission
+	Kind          string          `json:"kind"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	DeliveredAt   time.Time       `json:"deliveredAt,omitempty"`
+}
+
+// NewJob builds a pending job for kind with payload marshalled to JSON.
+func NewJob(leadID, kind string, payload any) (Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+	return Job{ID: leadID + ":" + kind, LeadID: leadID, Kind: kind, Payload: raw}, nil
+}
+
+// NewLeadID returns a random ID for a submission.
+func NewLeadID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "lead_" + hex.EncodeToString(b)
+}
+
+// Store persists jobs. Enqueue must be durable before it returns.
+type Store interface {
+	// Enqueue stores all jobs in one write; jobs whose ID already exists are ignored.
+	Enqueue(jobs ...Job) error
+	// Due returns pending jobs whose next attempt is at or before now, oldest first.
+	Due(now time.Time, limit int) []Job
+	MarkDelivered(id string, at time.Time) error
+	// MarkFailed records an attempt; dead moves the job to the dead-letter state.
+	MarkFailed(id, reason string, next time.Time, dead bool) error
+	DeadLetters() []Job
+	// Requeue puts a dead job back in the queue with a fresh set of attempts.
+	Requeue(id string, at time.Time) error
+	// Prune drops delivered jobs older than before and reports how many were removed.
+	Prune(before time.Time) int
+}
+
+// NewFromEnv returns a file store at LEAD_OUTBOX_PATH (default data/lead-outbox.json).
+// Set LEAD_OUTBOX_PATH=memory to keep the outbox in memory only (tests, throwaway demos).
+func NewFromEnv() Store {
+	return NewFileStore(config.Get().Stores.LeadOutbox)
+}
+
+// FileStore keeps jobs in memory and snapshots them to a JSON file on each write.
+// jsonstore fsyncs each snapshot before save returns, so an acknowledged lead survives
+// a crash. Only one process may use a given file; jsonstore logs when another holds it.
+type FileStore struct {
+	mu   sync.Mutex
+	file *jsonstore.File
+	jobs map[string]Job
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{jobs: make(map[string]Job)}
+	s.file = jsonstore.Open("leadoutbox", path, &s.jobs)
+	return s
+}
+
+func (s *FileStore) Enqueue(jobs ...Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	added := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		if j.ID == "" || j.Kind == "" {
+			s.rollback(added)
+			return errors.New("leadoutbox: job id and kind are required")
+		}
+		if _, exists := s.jobs[j.ID]; exists {
+			continue
+		}
+		j.Status = StatusPending
+		j.CreatedAt = now
+		j.UpdatedAt = now
+		j.NextAttemptAt = now
+		s.jobs[j.ID] = j
+		added = append(added, j.ID)
+	}
+	if err := s.save(); err != nil {
+		s.rollback(added)
+		return err
+	}
+	return nil
+}
+
+// rollback forgets jobs that could not be persisted. Caller holds s.mu.
+func (s *FileStore) rollback(ids []string) {
+	for _, id := range ids {
+		delete(s.jobs, id)
+	}
+}
+
+func (s *FileStore) Due(now time.Time, limit int) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0)
+	for _, j := range s.jobs {
+		if j.Status == StatusPending && !j.NextAttemptAt.After(now) {
+			out = append(out, j)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].CreatedAt.Before(out[k].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (s *FileStore) MarkDelivered(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	j.Status = StatusDelivered
+	j.Attempts++
+	j.LastError = ""
+	j.DeliveredAt = at.UTC()
+	j.UpdatedAt = at.UTC()
+	s.jobs[id] = j
+	return s.save()
+}
+
+func (s *FileStore) MarkFailed(id, reason string, next time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	j.Attempts++
+	j.LastError = reason
+	j.NextAttemptAt = next.UTC()
+	j.UpdatedAt = time.Now().UTC()
+	if dead {
+		j.Status = StatusDead
+	}
+	s.jobs[id] = j
+	return s.save()
+}
+
+func (s *FileStore) DeadLetters() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0)
+	for _, j := range s.jobs {
+		if j.Status == StatusDead {
+			out = append(out, j)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].CreatedAt.Before(out[k].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) Requeue(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if j.Status != StatusDead {
+		return ErrNotDead
+	}
+	prev := j
+	j.Status = StatusPending
+	j.Attempts = 0
+	j.NextAttemptAt = at.UTC()
+	j.UpdatedAt = at.UTC()
+	s.jobs[id] = j
+	if err := s.save(); err != nil {
+		s.jobs[id] = prev
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Prune(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for id, j := range s.jobs {
+		if j.Status == StatusDelivered && j.DeliveredAt.Before(before) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	if removed > 0 {
+		if err := s.save(); err != nil {
+			log.Printf("leadoutbox: prune: %v", err)
+		}
+	}
+	return removed
+}
+
+// save snapshots the jobs. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.jobs)
+}
@@ -0,0 +1,164 @@
+package leadoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Handler delivers one job. Return Permanent(err) when retrying cannot help.
+type Handler func(j Job) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the job is dead-lettered straight away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// Worker polls the store and hands due jobs to the handler registered for their kind.
+type Worker struct {
+	Store        Store
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	// Retention is how long delivered jobs are kept for troubleshooting.
+	Retention time.Duration
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	kick     chan struct{}
+}
+
+// NewWorkerFromEnv uses LEAD_OUTBOX_MAX_ATTEMPTS (default 8), LEAD_OUTBOX_BACKOFF_SECONDS
+// (first retry delay, default 30, doubling up to an hour) and LEAD_OUTBOX_POLL_SECONDS
+// (default 5).
+func NewWorkerFromEnv(store Store) *Worker {
+	cfg := config.Get().Leads
+	return &Worker{
+		Store:        store,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		BaseBackoff:  cfg.OutboxBackoff,
+		MaxBackoff:   time.Hour,
+		PollInterval: cfg.OutboxPoll,
+		Retention:    7 * 24 * time.Hour,
+		handlers:     make(map[string]Handler),
+		kick:         make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a job kind.
+func (w *Worker) Register(kind string, h Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[kind] = h
+}
+
+// Kick asks the worker to look for due jobs now instead of waiting for the next poll.
+func (w *Worker) Kick() {
+	select {
+	case w.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers due jobs until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("📮 Lead outbox: worker started (max %d attempts)", w.MaxAttempts)
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+	for {
+		w.RunOnce(ctx)
+		if time.Since(lastPrune) > time.Hour {
+			if n := w.Store.Prune(time.Now().Add(-w.Retention)); n > 0 {
+				log.Printf("lead outbox: pruned %d delivered jobs", n)
+			}
+			lastPrune = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.kick:
+		}
+	}
+}
+
+// RunOnce attempts every job that is due.
+func (w *Worker) RunOnce(ctx context.Context) {
+	for _, j := range w.Store.Due(time.Now(), 50) {
+		if ctx.Err() != nil {
+			return
+		}
+		w.attempt(j)
+	}
+}
+
+func (w *Worker) attempt(j Job) {
+	w.mu.RLock()
+	h, ok := w.handlers[j.Kind]
+	w.mu.RUnlock()
+
+	var err error
+	if ok {
+		err = h(j)
+	} else {
+		err = Permanent(fmt.Errorf("no handler for kind %q", j.Kind))
+	}
+
+	now := time.Now()
+	if err == nil {
+		if err := w.Store.MarkDelivered(j.ID, now); err != nil {
+			log.Printf("lead outbox: %s delivered but not recorded: %v", j.ID, err)
+		}
+		return
+	}
+
+	attempts := j.Attempts + 1
+	dead := IsPermanent(err) || attempts >= w.MaxAttempts
+	next := now.Add(w.backoff(attempts))
+	if dead {
+		log.Printf("lead outbox: dead-lettered %s after %d attempts: %v", j.ID, attempts, err)
+	} else {
+		log.Printf("lead outbox: %s attempt %d failed, retrying at %s: %v", j.ID, attempts, next.Format(time.RFC3339), err)
+	}
+	if err := w.Store.MarkFailed(j.ID, err.Error(), next, dead); err != nil {
+		log.Printf("lead outbox: could not record failure for %s: %v", j.ID, err)
+	}
+}
+
+// backoff doubles from BaseBackoff per attempt, capped at MaxBackoff, with up to 20% jitter
+// so a recovering upstream is not hit by every retry at once.
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.BaseBackoff
+	for i := 1; i < attempts && d < w.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.MaxBackoff {
+		d = w.MaxBackoff
+	}
+	if jitter := int64(d) / 5; jitter > 0 {
+		d += time.Duration(rand.Int63n(jitter))
+	}
+	return d
+}
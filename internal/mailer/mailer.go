@@ -0,0 +1,364 @@
+// Package mailer renders and sends HTML/text emails: the lead alert to our staff, the
+// acknowledgement to the enquirer, and the email channel of notify. Local runs write messages to a
+// maildir; non-production environments never deliver to customers.
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// ErrRecipient means an address cannot be delivered to; retrying will not help.
+var ErrRecipient = errors.New("mailer: invalid recipient")
+
+// Audiences decide how non-production environments treat a message.
+const (
+	AudienceStaff    = "staff"
+	AudienceCustomer = "customer"
+)
+
+// Email is one rendered message.
+type Email struct {
+	To          []string     `json:"to"`
+	ReplyTo     string       `json:"replyTo,omitempty"`
+	Subject     string       `json:"subject"`
+	Text        string       `json:"text"`
+	HTML        string       `json:"html,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Audience    string       `json:"audience"`
+	// OriginalTo is set when Guard redirected the message, for the X-Original-To header.
+	OriginalTo []string `json:"originalTo,omitempty"`
+}
+
+// Attachment is a file sent along with an email, such as a calendar invite.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"` // e.g. text/calendar; method=REQUEST
+	Data        []byte `json:"data"`
+}
+
+// Sender delivers an email or returns an error so the outbox can retry.
+type Sender interface {
+	Send(e Email) error
+}
+
+// NewFromEnv picks a sender from MAIL_PROVIDER (smtp, maildir) and wraps it in the
+// recipient policy for ENVIRONMENT. The default is a maildir at MAIL_MAILDIR_PATH
+// (default tmp/mail).
+func NewFromEnv() Sender {
+	cfg := config.Get()
+	var next Sender
+	switch cfg.Mail.Provider {
+	case "smtp":
+		next = SMTPSender{
+			Addr:     cfg.Mail.SMTP.Addr(),
+			Username: cfg.Mail.SMTP.Username,
+			Password: cfg.Mail.SMTP.Password,
+			From:     cfg.Mail.From,
+		}
+	default:
+		log.Printf("✉️  Mailer: writing emails to maildir %s", cfg.Mail.MaildirPath)
+		next = &Maildir{Dir: cfg.Mail.MaildirPath, From: cfg.Mail.From}
+	}
+	return Guard{
+		Next:        next,
+		Environment: cfg.Environment,
+		RedirectTo:  cfg.Mail.RedirectTo,
+	}
+}
+
+// Guard applies the per-environment recipient policy. When RedirectTo is set every
+// message goes there instead, tagged with the environment. Otherwise production mail
+// goes where it is addressed, and other environments deliver staff mail but drop
+// customer mail, so staging never reaches a real enquirer.
+type Guard struct {
+	Next        Sender
+	Environment string
+	RedirectTo  []string
+}
+
+func (g Guard) Send(e Email) error {
+	env := firstNonEmpty(g.Environment, "local")
+	switch {
+	case len(g.RedirectTo) > 0:
+		e.OriginalTo = e.To
+		e.To = g.RedirectTo
+		e.Subject = "[" + env + "] " + e.Subject
+	case env == "production" || env == "prod":
+	case e.Audience == AudienceCustomer:
+		log.Printf("mailer: %s environment - not emailing customer %s (%q)", env, strings.Join(e.To, ", "), e.Subject)
+		return nil
+	}
+	return g.Next.Send(e)
+}
+
+// SMTPSender delivers through an SMTP relay.
+type SMTPSender struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(e Email) error {
+	if strings.HasPrefix(s.Addr, ":") || s.From == "" {
+		return errors.New("mailer: SMTP_HOST and MAIL_FROM or SMTP_FROM are required")
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("mailer: from address: %w", err)
+	}
+	to, err := addresses(e.To)
+	if err != nil {
+		return err
+	}
+	msg, err := e.Bytes(s.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	return smtp.SendMail(s.Addr, auth, from.Address, to, msg)
+}
+
+// Maildir writes each message as a file in Dir/new, readable by any mail client
+// that opens maildirs or .eml files.
+type Maildir struct {
+	Dir  string
+	From string
+
+	mu sync.Mutex
+}
+
+func (m *Maildir) Send(e Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := addresses(e.To); err != nil {
+		return err
+	}
+	msg, err := e.Bytes(m.From, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.Dir, sub), 0o755); err != nil {
+			return fmt.Errorf("mailer maildir: %w", err)
+		}
+	}
+	name := fmt.Sprintf("%d.%d_%s.dhakahome.eml", time.Now().UnixNano(), os.Getpid(), randomHex(4))
+	tmp := filepath.Join(m.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, msg, 0o600); err != nil {
+		return fmt.Errorf("mailer maildir: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(m.Dir, "new", name))
+}
+
+// Bytes renders the email as an RFC 5322 message: multipart/alternative with
+// quoted-printable text and HTML parts, or plain text when there is no HTML. With
+// attachments that body becomes the first part of a multipart/mixed message.
+func (e Email) Bytes(from string, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, headerValue(v)) }
+
+	header("From", encodeAddressList([]string{from}))
+	header("To", encodeAddressList(e.To))
+	if e.ReplyTo != "" {
+		header("Reply-To", encodeAddressList([]string{e.ReplyTo}))
+	}
+	if len(e.OriginalTo) > 0 {
+		header("X-Original-To", strings.Join(e.OriginalTo, ", "))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", e.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+randomHex(12)+"@dhakahome>")
+	header("MIME-Version", "1.0")
+
+	contentType, encoding, body, err := e.body()
+	if err != nil {
+		return nil, err
+	}
+	if len(e.Attachments) == 0 {
+		header("Content-Type", contentType)
+		if encoding != "" {
+			header("Content-Transfer-Encoding", encoding)
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	part := textproto.MIMEHeader{"Content-Type": {contentType}}
+	if encoding != "" {
+		part.Set("Content-Transfer-Encoding", encoding)
+	}
+	w, err := mw.CreatePart(part)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	for _, a := range e.Attachments {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.mediaType()},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body renders the readable part of the message and returns its Content-Type and
+// Content-Transfer-Encoding.
+func (e Email) body() (string, string, []byte, error) {
+	var buf bytes.Buffer
+	if e.HTML == "" {
+		if err := writeQP(&buf, e.Text); err != nil {
+			return "", "", nil, err
+		}
+		return "text/plain; charset=UTF-8", "quoted-printable", buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	for _, part := range []struct{ typ, body string }{
+		{"text/plain; charset=UTF-8", e.Text},
+		{"text/html; charset=UTF-8", e.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", "", nil, err
+		}
+		if err := writeQP(w, part.body); err != nil {
+			return "", "", nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", "", nil, err
+	}
+	return "multipart/alternative; boundary=" + mw.Boundary(), "", buf.Bytes(), nil
+}
+
+func writeQP(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// mediaType is the attachment's Content-Type with its file name added, falling back
+// to application/octet-stream when ContentType does not parse.
+func (a Attachment) mediaType() string {
+	typ, params, err := mime.ParseMediaType(a.ContentType)
+	if err != nil {
+		typ, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = a.Name
+	return mime.FormatMediaType(typ, params)
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+// addresses validates recipients and returns the bare addresses for the SMTP envelope.
+func addresses(list []string) ([]string, error) {
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrRecipient)
+	}
+	out := make([]string, 0, len(list))
+	for _, raw := range list {
+		addr, err := mail.ParseAddress(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrRecipient, raw, err)
+		}
+		out = append(out, addr.Address)
+	}
+	return out, nil
+}
+
+// encodeAddressList formats addresses with Q-encoded display names.
+func encodeAddressList(list []string) string {
+	out := make([]string, 0, len(list))
+	for _, raw := range list {
+		if addr, err := mail.ParseAddress(raw); err == nil {
+			out = append(out, addr.String())
+		} else {
+			out = append(out, raw)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// headerValue keeps user-controlled text from injecting extra headers.
+func headerValue(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// TemplateDir holds the email templates, relative to the working directory like the page views.
+var TemplateDir = filepath.Join("internal", "views", "emails")
+
+// Lead is what the lead emails show.
+type Lead struct {
+	Reference     string
+	Form          string // lead, requirements, viewing
+	Name          string
+	Email         string
+	Phone         string
+	Message       string
+	PropertyID    string
+	PropertyTitle string
+	PropertyURL   string
+	Details       []Detail
+	AssignedTo    string
+	Source        string
+	SiteURL       string
+	At            time.Time
+}
+
+// Detail is one labelled line, such as a budget or move-in date.
+type Detail struct {
+	Label string
+	Value string
+}
+
+// LeadAlert renders the internal alert for staff; replies go to the enquirer.
+func LeadAlert(l Lead, to []string) (Email, error) {
+	e := Email{To: to, ReplyTo: l.Email, Audience: AudienceStaff}
+	return e, render("lead-alert", l, &e)
+}
+
+// LeadAcknowledgement renders the "we got your enquiry" email for the enquirer.
+func LeadAcknowledgement(l Lead) (Email, error) {
+	e := Email{To: []string{l.Email}, Audience: AudienceCustomer}
+	return e, render("lead-ack", l, &e)
+}
+
+var templateFuncs = map[string]any{
+	"when": func(t time.Time) string {
+		if loc, err := time.LoadLocation("Asia/Dhaka"); err == nil {
+			t = t.In(loc)
+		}
+		return t.Format("Mon 2 Jan 2006, 3:04 PM")
+	},
+	"formLabel": func(form string) string {
+		switch form {
+		case "requirements":
+			return "Requirement request"
+		case "viewing":
+			return "Viewing request"
+		default:
+			return "Enquiry"
+		}
+	},
+}
+
+// render fills Subject and Text from name.txt (which defines "subject") and HTML
+// from name.html.
+func render(name string, data any, e *Email) error {
+	txt, err := texttemplate.New(name + ".txt").Funcs(templateFuncs).ParseFiles(filepath.Join(TemplateDir, name+".txt"))
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	var subject, text bytes.Buffer
+	if err := txt.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("mailer: %s subject: %w", name, err)
+	}
+	if err := txt.Execute(&text, data); err != nil {
+		return fmt.Errorf("mailer: %s text: %w", name, err)
+	}
+
+	page, err := htmltemplate.New(name + ".html").Funcs(templateFuncs).ParseFiles(filepath.Join(TemplateDir, name+".html"))
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	var html bytes.Buffer
+	if err := page.Execute(&html, data); err != nil {
+		return fmt.Errorf("mailer: %s html: %w", name, err)
+	}
+
+	e.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	e.Text = strings.TrimSpace(text.String()) + "\n"
+	e.HTML = html.String()
+	return nil
+}
@@ -0,0 +1,44 @@
+package mw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// CORS allows cross-origin calls from the given origins only. An empty list allows
+// none, which suits production where the site and its API share an origin.
+func CORS(origins []string) func(http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With", "HX-Request", "HX-Current-URL", "HX-Target", "HX-Trigger"},
+		ExposedHeaders:   []string{"X-Request-Id", "Retry-After"},
+		AllowCredentials: false,
+		MaxAge:           300,
+	})
+}
+
+// CORSOrigins parses configured, the comma-separated CORS_ALLOWED_ORIGINS. When empty, local
+// environments allow the dev server and PUBLIC_SITE_URL; others allow only
+// PUBLIC_SITE_URL.
+func CORSOrigins(env, configured, siteURL string) []string {
+	var out []string
+	for _, o := range strings.Split(configured, ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			out = append(out, o)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	if site := strings.TrimRight(strings.TrimSpace(siteURL), "/"); site != "" {
+		out = append(out, site)
+	}
+	switch env {
+	case "", "local", "development", "dev":
+		out = append(out, "http://localhost:5173", "http://127.0.0.1:5173")
+	}
+	return out
+}
@@ -1,12 +1,100 @@
+// Package mw holds the HTTP middleware stack shared by every route: request IDs,
+// client IPs, access logs, panic recovery, timeouts, compression, body limits and CORS.
 package mw
 
 import (
-    "net/http"
+	"io"
+	"net/http"
+	"strings"
+	"time"
 
-    "github.com/go-chi/httplog"
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog"
 )
 
-func RequestLogger() func(next http.Handler) http.Handler {
-    l := httplog.NewLogger("dhakahome-web", httplog.Options{Concise: true})
-    return httplog.RequestLogger(l)
+// RequestID tags each request with an ID, reusing an incoming X-Request-Id, and echoes
+// it in the response so a visitor's report can be matched to the logs.
+func RequestID(next http.Handler) http.Handler {
+	return middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// RealIP takes the client address from X-Forwarded-For / X-Real-IP when trusted is
+// set. Only enable it behind a proxy that overwrites those headers, otherwise clients
+// can pick their own IP and slip past the rate limits.
+func RealIP(trusted bool) func(http.Handler) http.Handler {
+	if !trusted {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware.RealIP
+}
+
+// RequestLogger writes one access log line per request: JSON outside local, so log
+// aggregators can parse it, and concise console output locally. Static assets and
+// health checks are skipped.
+func RequestLogger(env string) func(http.Handler) http.Handler {
+	local := env == "" || env == "local" || env == "development" || env == "dev"
+	l := httplog.NewLogger("dhakahome-web", httplog.Options{
+		JSON:        !local,
+		Concise:     true,
+		Tags:        map[string]string{"env": env},
+		SkipHeaders: []string{"x-csrf-token"},
+	})
+	logged := httplog.Handler(l)
+	return func(next http.Handler) http.Handler {
+		withLog := logged(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if quietPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withLog.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout answers 503 when a handler takes longer than d. The handler's response is
+// buffered, so do not use it on streaming routes.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, "The request took too long. Please try again.")
+	}
+}
+
+// BodyLimit caps request bodies at n bytes; larger bodies fail to read.
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Compress encodes text responses with brotli when the browser accepts it, falling
+// back to gzip and deflate.
+func Compress() func(http.Handler) http.Handler {
+	c := middleware.NewCompressor(5,
+		"text/html", "text/css", "text/plain", "text/javascript", "text/csv",
+		"application/javascript", "application/json", "application/xml",
+		"image/svg+xml", "text/calendar",
+	)
+	c.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return c.Handler
+}
+
+func quietPath(p string) bool {
+	for _, prefix := range []string{"/assets/", "/healthz", "/readyz", "/favicon"} {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
 }
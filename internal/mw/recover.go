@@ -0,0 +1,50 @@
+package mw
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer turns a panic into a 500 served by page, and logs the panic and stack
+// with the request ID. If page itself panics a plain-text error is sent instead.
+func Recoverer(page http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					// the client went away; let net/http drop the connection quietly
+					panic(rvr)
+				}
+				stack := debug.Stack()
+				if entry := middleware.GetLogEntry(r); entry != nil {
+					entry.Panic(rvr, stack)
+				} else {
+					log.Printf("panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, middleware.GetReqID(r.Context()), rvr, stack)
+				}
+				serveErrorPage(w, r, page)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func serveErrorPage(w http.ResponseWriter, r *http.Request, page http.HandlerFunc) {
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			log.Printf("error page failed: %v", rvr)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	}()
+	if page == nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	page(w, r)
+}
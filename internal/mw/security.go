@@ -0,0 +1,168 @@
+package mw
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/session"
+)
+
+// CSP modes for SecurityOptions.CSP.
+const (
+	CSPEnforce    = "enforce"
+	CSPReportOnly = "report-only"
+	CSPOff        = "off"
+)
+
+// SecurityOptions configures SecurityHeaders.
+type SecurityOptions struct {
+	// CSP is CSPEnforce, CSPReportOnly (violations are reported, nothing is blocked)
+	// or CSPOff.
+	CSP string
+	// ReportURI receives violation reports, e.g. /csp-report.
+	ReportURI string
+	// HSTSMaxAge is sent on HTTPS responses; zero leaves HSTS off.
+	HSTSMaxAge time.Duration
+}
+
+// Third-party origins the pages load scripts, styles, tiles and captchas from.
+var (
+	scriptHosts = []string{
+		"https://unpkg.com", "https://cdnjs.cloudflare.com", "https://api.mapbox.com",
+		"https://challenges.cloudflare.com", "https://js.hcaptcha.com", "https://*.hcaptcha.com",
+		"https://www.google.com", "https://www.gstatic.com",
+	}
+	connectHosts = []string{
+		"https://api.mapbox.com", "https://events.mapbox.com", "https://*.tiles.mapbox.com",
+		"https://*.tile.openstreetmap.org", "https://unpkg.com", "https://cdnjs.cloudflare.com",
+		"https://*.hcaptcha.com",
+	}
+	frameHosts = []string{
+		"https://challenges.cloudflare.com", "https://*.hcaptcha.com", "https://www.google.com",
+	}
+)
+
+type nonceKey struct{}
+
+// SecurityHeaders sets nosniff, Referrer-Policy, Permissions-Policy, HSTS on HTTPS, and
+// a Content-Security-Policy whose per-request nonce templates read with CSPNonce.
+func SecurityHeaders(o SecurityOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			h.Set("Permissions-Policy", "camera=(), microphone=(), payment=(), usb=(), interest-cohort=(), geolocation=(self)")
+			h.Set("X-Frame-Options", "SAMEORIGIN")
+			if o.HSTSMaxAge > 0 && session.IsSecure(r) {
+				h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(o.HSTSMaxAge.Seconds())))
+			}
+
+			if o.CSP != CSPOff {
+				nonce := newNonce()
+				header := "Content-Security-Policy"
+				if o.CSP == CSPReportOnly {
+					header = "Content-Security-Policy-Report-Only"
+				}
+				h.Set(header, policy(nonce, o.ReportURI))
+				r = r.WithContext(context.WithValue(r.Context(), nonceKey{}, nonce))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSPNonce returns the request's nonce for inline <script nonce="..."> tags.
+func CSPNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(nonceKey{}).(string)
+	return nonce
+}
+
+// policy builds the CSP. Styles keep 'unsafe-inline' because the templates style
+// elements inline; scripts must carry the nonce or come from an allowed host.
+func policy(nonce, reportURI string) string {
+	join := func(hosts []string) string { return strings.Join(hosts, " ") }
+	directives := []string{
+		"default-src 'self'",
+		"script-src 'self' 'nonce-" + nonce + "' " + join(scriptHosts),
+		"style-src 'self' 'unsafe-inline' https://api.mapbox.com https://unpkg.com https://cdnjs.cloudflare.com https://*.hcaptcha.com",
+		"img-src 'self' data: blob: https:",
+		"font-src 'self' data:",
+		"connect-src 'self' " + join(connectHosts),
+		"frame-src " + join(frameHosts),
+		"worker-src 'self' blob:",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors 'self'",
+	}
+	if reportURI != "" {
+		directives = append(directives, "report-uri "+reportURI)
+	}
+	return strings.Join(directives, "; ")
+}
+
+// CSPReport handles POST /csp-report, logging each violation. It accepts both the
+// legacy application/csp-report body and Reporting API batches.
+func CSPReport(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var violations []map[string]any
+	var legacy struct {
+		Report map[string]any `json:"csp-report"`
+	}
+	var batch []struct {
+		Type string         `json:"type"`
+		Body map[string]any `json:"body"`
+	}
+	switch {
+	case json.Unmarshal(body, &legacy) == nil && legacy.Report != nil:
+		violations = append(violations, legacy.Report)
+	case json.Unmarshal(body, &batch) == nil:
+		for _, rep := range batch {
+			if rep.Type == "csp-violation" && rep.Body != nil {
+				violations = append(violations, rep.Body)
+			}
+		}
+	}
+	for _, v := range violations {
+		log.Printf("csp violation: %s blocked %s on %s (disposition %s)",
+			field(v, "effective-directive", "effectiveDirective", "violated-directive"),
+			field(v, "blocked-uri", "blockedURL"),
+			field(v, "document-uri", "documentURL"),
+			field(v, "disposition"))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func field(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			if len(s) > 200 {
+				s = s[:200]
+			}
+			return s
+		}
+	}
+	return "-"
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,140 @@
+// Package notify delivers short customer notifications (alerts, digests) by email or SMS.
+// Local runs write everything to an outbox file so nothing leaves the machine.
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/mailer"
+	"github.com/BohoBytes/dhakahome-web/internal/sms"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Message is one notification to one recipient. Subject and Attachments are ignored for SMS.
+type Message struct {
+	Channel     string       `json:"channel"`
+	To          string       `json:"to"`
+	Subject     string       `json:"subject,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file sent along with an email, such as a calendar invite.
+type Attachment = mailer.Attachment
+
+// Notifier delivers a message or returns an error so the caller can retry later.
+type Notifier interface {
+	Send(m Message) error
+}
+
+// NewFromEnv picks a notifier based on NOTIFY_PROVIDER (outbox, live). Live email goes
+// through the mailer (MAIL_PROVIDER, MAIL_REDIRECT_TO) and SMS through SMS_PROVIDER.
+// Anything but "live" writes to the outbox at NOTIFY_OUTBOX_PATH (default tmp/notify-outbox.log).
+func NewFromEnv() Notifier {
+	cfg := config.Get()
+	if cfg.Notify.Provider == "live" {
+		return Channels{
+			Email: MailNotifier{Sender: mailer.NewFromEnv()},
+			SMS:   SMSNotifier{Sender: sms.NewFromEnv()},
+		}
+	}
+	log.Printf("🔔 Notify: writing notifications to %s", cfg.Notify.OutboxPath)
+	return &Outbox{Path: cfg.Notify.OutboxPath}
+}
+
+// Channels routes each message to the notifier for its channel.
+type Channels struct {
+	Email Notifier
+	SMS   Notifier
+}
+
+func (c Channels) Send(m Message) error {
+	var n Notifier
+	switch m.Channel {
+	case ChannelEmail:
+		n = c.Email
+	case ChannelSMS:
+		n = c.SMS
+	}
+	if n == nil {
+		return fmt.Errorf("notify: no sender for channel %q", m.Channel)
+	}
+	return n.Send(m)
+}
+
+// Outbox appends messages as JSON lines to a local file for development.
+type Outbox struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (o *Outbox) Send(m Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(o.Path), 0o755); err != nil {
+		return fmt.Errorf("notify outbox: %w", err)
+	}
+	f, err := os.OpenFile(o.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("notify outbox: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(struct {
+		At time.Time `json:"at"`
+		Message
+	}{time.Now().UTC(), m})
+	if err != nil {
+		return fmt.Errorf("notify outbox: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("notify outbox: %w", err)
+	}
+	return nil
+}
+
+// SMSNotifier sends through the same gateway as login OTPs.
+type SMSNotifier struct {
+	Sender sms.Sender
+}
+
+func (s SMSNotifier) Send(m Message) error {
+	if s.Sender == nil {
+		return errors.New("notify: sms sender not configured")
+	}
+	return s.Sender.Send(m.To, m.Text)
+}
+
+// MailNotifier sends email through the mailer, so notifications get the same
+// per-environment recipient policy as lead mail: staging redirects or drops them
+// rather than reaching customers.
+type MailNotifier struct {
+	Sender mailer.Sender
+}
+
+func (n MailNotifier) Send(m Message) error {
+	if n.Sender == nil {
+		return errors.New("notify: mail sender not configured")
+	}
+	return n.Sender.Send(mailer.Email{
+		To:          []string{m.To},
+		Subject:     m.Subject,
+		Text:        m.Text,
+		Attachments: m.Attachments,
+		Audience:    mailer.AudienceCustomer,
+	})
+}
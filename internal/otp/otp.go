@@ -0,0 +1,211 @@
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+var (
+	ErrNoCode          = errors.New("otp: no active code for this number")
+	ErrExpired         = errors.New("otp: code expired")
+	ErrInvalidCode     = errors.New("otp: invalid code")
+	ErrTooManyAttempts = errors.New("otp: too many attempts")
+)
+
+// ThrottleError is returned when a new code is requested too soon.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("otp: resend throttled, retry in %ds", int(e.RetryAfter.Seconds()+0.5))
+}
+
+type Config struct {
+	CodeLength     int           // digits per code
+	TTL            time.Duration // how long a code stays valid
+	MaxAttempts    int           // wrong guesses before the code is burned
+	ResendInterval time.Duration // minimum gap between two codes for one number
+	MaxSendsPerDay int           // hard cap on codes per number per 24h
+}
+
+// ConfigFromEnv returns the OTP_* settings from the site configuration.
+func ConfigFromEnv() Config {
+	return Config(config.Get().OTP)
+}
+
+type entry struct {
+	hash      [32]byte
+	expiresAt time.Time
+	attempts  int
+	lastSent  time.Time
+	sends     []time.Time
+}
+
+// Store keeps one pending code per phone number in memory.
+type Store struct {
+	mu      sync.Mutex
+	cfg     Config
+	entries map[string]*entry
+	now     func() time.Time
+}
+
+func NewStore(cfg Config) *Store {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.ResendInterval < 0 {
+		cfg.ResendInterval = 0
+	}
+	if cfg.MaxSendsPerDay <= 0 {
+		cfg.MaxSendsPerDay = 10
+	}
+	return &Store{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+func (s *Store) TTL() time.Duration { return s.cfg.TTL }
+
+// Issue generates a fresh code for phone, replacing any pending one.
+// It enforces the resend interval and the daily send cap.
+func (s *Store) Issue(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", fmt.Errorf("otp: phone is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e := s.entries[phone]
+	if e == nil {
+		e = &entry{}
+		s.entries[phone] = e
+	}
+
+	if !e.lastSent.IsZero() {
+		if wait := e.lastSent.Add(s.cfg.ResendInterval).Sub(now); wait > 0 {
+			return "", &ThrottleError{RetryAfter: wait}
+		}
+	}
+
+	recent := e.sends[:0]
+	for _, t := range e.sends {
+		if now.Sub(t) < 24*time.Hour {
+			recent = append(recent, t)
+		}
+	}
+	e.sends = recent
+	if len(e.sends) >= s.cfg.MaxSendsPerDay {
+		return "", &ThrottleError{RetryAfter: e.sends[0].Add(24 * time.Hour).Sub(now)}
+	}
+
+	code, err := randomDigits(s.cfg.CodeLength)
+	if err != nil {
+		return "", err
+	}
+
+	e.hash = sha256.Sum256([]byte(phone + ":" + code))
+	e.expiresAt = now.Add(s.cfg.TTL)
+	e.attempts = 0
+	e.lastSent = now
+	e.sends = append(e.sends, now)
+
+	return code, nil
+}
+
+// Verify checks code for phone. A successful check consumes the code.
+func (s *Store) Verify(phone, code string) error {
+	phone = strings.TrimSpace(phone)
+	code = strings.TrimSpace(code)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	e := s.entries[phone]
+	if e == nil || e.expiresAt.IsZero() {
+		return ErrNoCode
+	}
+	if now.After(e.expiresAt) {
+		e.expiresAt = time.Time{}
+		return ErrExpired
+	}
+	if e.attempts >= s.cfg.MaxAttempts {
+		e.expiresAt = time.Time{}
+		return ErrTooManyAttempts
+	}
+
+	e.attempts++
+	got := sha256.Sum256([]byte(phone + ":" + code))
+	if subtle.ConstantTimeCompare(got[:], e.hash[:]) != 1 {
+		if e.attempts >= s.cfg.MaxAttempts {
+			e.expiresAt = time.Time{}
+			return ErrTooManyAttempts
+		}
+		return ErrInvalidCode
+	}
+
+	// Keep send history for throttling, but burn the code.
+	e.expiresAt = time.Time{}
+	e.hash = [32]byte{}
+	return nil
+}
+
+// AttemptsLeft reports how many guesses remain for the pending code.
+func (s *Store) AttemptsLeft(phone string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entries[strings.TrimSpace(phone)]
+	if e == nil || e.expiresAt.IsZero() {
+		return 0
+	}
+	left := s.cfg.MaxAttempts - e.attempts
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// sweep drops entries that have neither a live code nor recent sends. Caller holds s.mu.
+func (s *Store) sweep(now time.Time) {
+	for phone, e := range s.entries {
+		if !e.expiresAt.IsZero() && now.Before(e.expiresAt) {
+			continue
+		}
+		if len(e.sends) > 0 && now.Sub(e.sends[len(e.sends)-1]) < 24*time.Hour {
+			continue
+		}
+		delete(s.entries, phone)
+	}
+}
+
+func randomDigits(n int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + d.Int64()))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,151 @@
+// Package propertywatch lets users follow individual listings and tells them when the
+// price or status changes.
+package propertywatch
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+var ErrNotFound = errors.New("propertywatch: watch not found")
+
+// Snapshot is what the watcher last saw for a listing.
+type Snapshot struct {
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency,omitempty"`
+	Status   string  `json:"status"` // listing type / status, e.g. listed_rental or leased
+	Gone     bool    `json:"gone,omitempty"`
+}
+
+// Watch is one user following one listing.
+type Watch struct {
+	OwnerKey       string    `json:"ownerKey"`
+	AssetID        string    `json:"assetId"`
+	Title          string    `json:"title"`
+	Channel        string    `json:"channel"` // notify.ChannelEmail or notify.ChannelSMS
+	To             string    `json:"to"`
+	Last           Snapshot  `json:"last"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastCheckedAt  time.Time `json:"lastCheckedAt,omitempty"`
+	LastNotifiedAt time.Time `json:"lastNotifiedAt,omitempty"`
+}
+
+// Store persists watches keyed by owner and asset.
+type Store interface {
+	// Put creates or replaces the owner's watch on w.AssetID.
+	Put(w Watch) (Watch, error)
+	Get(ownerKey, assetID string) (Watch, bool)
+	ListByOwner(ownerKey string) []Watch
+	All() []Watch
+	Delete(ownerKey, assetID string) error
+	// Record stores a new snapshot after a check.
+	Record(ownerKey, assetID string, snap Snapshot, notified bool, at time.Time) error
+}
+
+// NewFromEnv returns a file store at PROPERTY_WATCH_PATH (default data/property-watches.json).
+// Set PROPERTY_WATCH_PATH=memory to keep watches in memory only.
+func NewFromEnv() Store {
+	return NewFileStore(config.Get().Stores.PropertyWatches)
+}
+
+// FileStore keeps watches in memory and snapshots them to a JSON file on each write.
+type FileStore struct {
+	mu      sync.Mutex
+	file    *jsonstore.File
+	watches map[string]map[string]Watch // owner -> asset -> watch
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{watches: make(map[string]map[string]Watch)}
+	s.file = jsonstore.Open("propertywatch", path, &s.watches)
+	return s
+}
+
+func (s *FileStore) Put(w Watch) (Watch, error) {
+	if w.OwnerKey == "" || w.AssetID == "" || w.To == "" {
+		return Watch{}, errors.New("propertywatch: owner, asset and recipient are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watches[w.OwnerKey] == nil {
+		s.watches[w.OwnerKey] = make(map[string]Watch)
+	}
+	if prev, ok := s.watches[w.OwnerKey][w.AssetID]; ok {
+		w.CreatedAt = prev.CreatedAt
+	} else {
+		w.CreatedAt = time.Now().UTC()
+	}
+	s.watches[w.OwnerKey][w.AssetID] = w
+	return w, s.save()
+}
+
+func (s *FileStore) Get(ownerKey, assetID string) (Watch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.watches[ownerKey][assetID]
+	return w, ok
+}
+
+func (s *FileStore) ListByOwner(ownerKey string) []Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Watch, 0, len(s.watches[ownerKey]))
+	for _, w := range s.watches[ownerKey] {
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) All() []Watch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Watch, 0)
+	for _, byAsset := range s.watches {
+		for _, w := range byAsset {
+			out = append(out, w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) Delete(ownerKey, assetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.watches[ownerKey][assetID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.watches[ownerKey], assetID)
+	if len(s.watches[ownerKey]) == 0 {
+		delete(s.watches, ownerKey)
+	}
+	return s.save()
+}
+
+func (s *FileStore) Record(ownerKey, assetID string, snap Snapshot, notified bool, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.watches[ownerKey][assetID]
+	if !ok {
+		// Unwatched while the check was in flight.
+		return ErrNotFound
+	}
+	w.Last = snap
+	w.LastCheckedAt = at.UTC()
+	if notified {
+		w.LastNotifiedAt = at.UTC()
+	}
+	s.watches[ownerKey][assetID] = w
+	return s.save()
+}
+
+// save snapshots the watches. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.watches)
+}
@@ -0,0 +1,194 @@
+package propertywatch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/notify"
+)
+
+// Watcher re-fetches every watched listing on a schedule and notifies owners of
+// price and status changes.
+type Watcher struct {
+	Store    Store
+	Notifier notify.Notifier
+	// Lookup fetches a listing; found is false once it has been withdrawn.
+	Lookup   func(id string) (api.Property, bool, error)
+	SiteURL  string
+	Interval time.Duration
+}
+
+// NewWatcherFromEnv wires a watcher for the given store. PROPERTY_WATCH_INTERVAL_MINUTES
+// sets the period (default 30) and PUBLIC_SITE_URL the base for links.
+func NewWatcherFromEnv(store Store) *Watcher {
+	cfg := config.Get()
+	return &Watcher{
+		Store:    store,
+		Notifier: notify.NewFromEnv(),
+		Lookup:   func(id string) (api.Property, bool, error) { return api.New().LookupProperty(id) },
+		SiteURL:  cfg.SiteURL(),
+		Interval: cfg.Alerts.PropertyWatchInterval,
+	}
+}
+
+// SnapshotOf reduces a listing to the fields the watcher compares.
+func SnapshotOf(p api.Property) Snapshot {
+	return Snapshot{Price: p.Price, Currency: p.Currency, Status: normalizeStatus(p.ListingType)}
+}
+
+// Run checks all watches now and then on every tick until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	log.Printf("👀 Property watches: checking for changes every %s", w.Interval)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		w.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type lookupResult struct {
+	prop  api.Property
+	found bool
+	err   error
+}
+
+// RunOnce fetches each watched listing once, however many users watch it. A snapshot is
+// only advanced after its notification has been handed off, so failed sends retry.
+func (w *Watcher) RunOnce(ctx context.Context) {
+	results := make(map[string]lookupResult)
+	for _, watch := range w.Store.All() {
+		if ctx.Err() != nil {
+			return
+		}
+		res, ok := results[watch.AssetID]
+		if !ok {
+			res.prop, res.found, res.err = w.Lookup(watch.AssetID)
+			results[watch.AssetID] = res
+		}
+		if res.err != nil {
+			log.Printf("property watch %s: %v", watch.AssetID, res.err)
+			continue
+		}
+		if err := w.check(watch, res); err != nil {
+			log.Printf("property watch %s for %s: %v", watch.AssetID, watch.OwnerKey, err)
+		}
+	}
+}
+
+func (w *Watcher) check(watch Watch, res lookupResult) error {
+	now := time.Now()
+	snap := Snapshot{Gone: true, Status: watch.Last.Status, Price: watch.Last.Price, Currency: watch.Last.Currency}
+	if res.found {
+		snap = SnapshotOf(res.prop)
+	}
+
+	changes := describeChanges(watch.Last, snap)
+	if watch.Last.Status == "" || len(changes) == 0 {
+		return w.Store.Record(watch.OwnerKey, watch.AssetID, snap, false, now)
+	}
+	if err := w.Notifier.Send(w.message(watch, changes)); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return w.Store.Record(watch.OwnerKey, watch.AssetID, snap, true, now)
+}
+
+func (w *Watcher) message(watch Watch, changes []string) notify.Message {
+	link := w.SiteURL + "/properties/" + url.PathEscape(watch.AssetID)
+	title := watch.Title
+	if title == "" {
+		title = "a property you are watching"
+	}
+	summary := strings.Join(changes, "; ")
+
+	if watch.Channel == notify.ChannelSMS {
+		return notify.Message{
+			Channel: notify.ChannelSMS,
+			To:      watch.To,
+			Text:    fmt.Sprintf("DhakaHome: %s - %s. %s", title, summary, link),
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Update on %s:\n\n", title)
+	for _, c := range changes {
+		fmt.Fprintf(&b, "- %s\n", upperFirst(c))
+	}
+	fmt.Fprintf(&b, "\nView the listing: %s\n", link)
+	fmt.Fprintf(&b, "Stop watching from the listing page at any time.\n")
+	return notify.Message{
+		Channel: notify.ChannelEmail,
+		To:      watch.To,
+		Subject: "DhakaHome: " + upperFirst(changes[0]) + " on " + title,
+		Text:    b.String(),
+	}
+}
+
+// describeChanges lists human-readable differences between two snapshots.
+func describeChanges(prev, next Snapshot) []string {
+	changes := make([]string, 0, 2)
+	if next.Gone {
+		if !prev.Gone {
+			changes = append(changes, "no longer listed")
+		}
+		return changes
+	}
+	if prev.Gone {
+		changes = append(changes, "listed again")
+	}
+	if prev.Price > 0 && next.Price > 0 && math.Abs(prev.Price-next.Price) >= 1 {
+		verb := "price dropped"
+		if next.Price > prev.Price {
+			verb = "price went up"
+		}
+		changes = append(changes, fmt.Sprintf("%s from %s to %s", verb, money(prev), money(next)))
+	}
+	if prev.Status != "" && next.Status != prev.Status {
+		changes = append(changes, fmt.Sprintf("status changed from %s to %s", StatusLabel(prev.Status), StatusLabel(next.Status)))
+	}
+	return changes
+}
+
+// StatusLabel turns a listing status into the wording used on the site.
+func StatusLabel(status string) string {
+	switch normalizeStatus(status) {
+	case "listed_rental":
+		return "For rent"
+	case "listed_sale":
+		return "For sale"
+	case "":
+		return "Unknown"
+	}
+	s := strings.ReplaceAll(normalizeStatus(status), "_", " ")
+	return upperFirst(s)
+}
+
+func normalizeStatus(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+}
+
+func money(s Snapshot) string {
+	currency := strings.ToUpper(strings.TrimSpace(s.Currency))
+	if currency == "" || currency == "৳" {
+		currency = "BDT"
+	}
+	return currency + " " + strconv.FormatFloat(s.Price, 'f', 0, 64)
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
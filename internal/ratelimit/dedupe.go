@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Submission is a lead remembered for dedupe.
+type Submission struct {
+	Reference string
+	Message   string
+	// Owner is whoever handles the submission, e.g. the inbox it was routed to.
+	Owner string
+	At    time.Time
+}
+
+// Dedupe remembers recent submissions by contact and property so repeats within
+// Window can be merged into the first one instead of creating new leads.
+type Dedupe struct {
+	Window time.Duration
+
+	mu   sync.Mutex
+	seen map[string]Submission
+}
+
+func NewDedupe(window time.Duration) *Dedupe {
+	return &Dedupe{Window: window, seen: make(map[string]Submission)}
+}
+
+// DedupeKeys returns the keys a lead is remembered under: one per contact detail,
+// each paired with the property, normalised like ByFields.
+func DedupeKeys(propertyID string, contacts ...string) []string {
+	propertyID = strings.TrimSpace(propertyID)
+	var keys []string
+	for _, c := range contacts {
+		if v := normalizeContact(c); v != "" {
+			keys = append(keys, v+"|"+propertyID)
+		}
+	}
+	return keys
+}
+
+// Recent returns the submission stored under any of keys within the window.
+func (d *Dedupe) Recent(keys []string, now time.Time) (Submission, bool) {
+	if d == nil || d.Window <= 0 {
+		return Submission{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, k := range keys {
+		if s, ok := d.seen[k]; ok && now.Sub(s.At) < d.Window {
+			return s, true
+		}
+	}
+	return Submission{}, false
+}
+
+// Remember stores s under every key, dropping entries that have expired.
+func (d *Dedupe) Remember(keys []string, s Submission) {
+	if d == nil || d.Window <= 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, old := range d.seen {
+		if s.At.Sub(old.At) >= d.Window {
+			delete(d.seen, k)
+		}
+	}
+	for _, k := range keys {
+		d.seen[k] = s
+	}
+}
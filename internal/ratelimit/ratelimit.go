@@ -0,0 +1,232 @@
+// Package ratelimit throttles abusive clients with sliding-window limits keyed by IP
+// or by fields of the submitted form, such as phone and email.
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store counts hits per key. MemoryStore suits a single instance; a shared store
+// (Redis and the like) can implement the same interface for several.
+type Store interface {
+	// Take records a hit for key unless limit hits already fall within the window
+	// ending at now. When refused it reports how long until the oldest hit expires.
+	Take(key string, limit int, window time.Duration, now time.Time) (ok bool, retryAfter time.Duration)
+}
+
+// MemoryStore keeps a log of hit times per key in process.
+type MemoryStore struct {
+	mu    sync.Mutex
+	hits  map[string][]time.Time
+	sweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{hits: make(map[string][]time.Time)}
+}
+
+func (s *MemoryStore) Take(key string, limit int, window time.Duration, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.sweep) > time.Minute {
+		s.prune(now, window)
+		s.sweep = now
+	}
+
+	cutoff := now.Add(-window)
+	recent := s.hits[key][:0]
+	for _, t := range s.hits[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= limit {
+		s.hits[key] = recent
+		return false, recent[0].Sub(cutoff)
+	}
+	s.hits[key] = append(recent, now)
+	return true, 0
+}
+
+// prune drops keys with no hits inside the longest window seen. Caller holds s.mu.
+func (s *MemoryStore) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-maxDuration(window, time.Hour))
+	for key, hits := range s.hits {
+		if len(hits) == 0 || !hits[len(hits)-1].After(cutoff) {
+			delete(s.hits, key)
+		}
+	}
+}
+
+// Rule allows Limit requests per Window for each key Key returns. Keys are
+// namespaced by Name, so rules can share a store.
+type Rule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+// KeyFunc extracts the keys a request is counted under; none means the rule does not apply.
+type KeyFunc func(r *http.Request, body []byte) []string
+
+// Limiter applies its rules in order; the first one exhausted rejects the request.
+type Limiter struct {
+	Store Store
+	Rules []Rule
+	Now   func() time.Time
+}
+
+// Handler wraps next, answering refused requests with 429 and a JSON error.
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := peekBody(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body", 0)
+			return
+		}
+		now := time.Now()
+		if l.Now != nil {
+			now = l.Now()
+		}
+		for _, rule := range l.Rules {
+			if rule.Limit <= 0 || rule.Window <= 0 || rule.Key == nil {
+				continue
+			}
+			for _, key := range rule.Key(r, body) {
+				if key == "" {
+					continue
+				}
+				if ok, wait := l.Store.Take(rule.Name+"|"+key, rule.Limit, rule.Window, now); !ok {
+					writeError(w, http.StatusTooManyRequests, "Too many attempts. Please wait a moment and try again.", wait)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ByIP keys requests by client address.
+func ByIP(r *http.Request, _ []byte) []string {
+	return []string{ClientIP(r)}
+}
+
+// ByFields keys requests by the given JSON or form fields. Emails are compared
+// case-insensitively and phone numbers by their last ten digits, so "017..." and
+// "+88017..." count as the same person.
+func ByFields(fields ...string) KeyFunc {
+	return func(r *http.Request, body []byte) []string {
+		values := fieldValues(r, body, fields)
+		var keys []string
+		for _, f := range fields {
+			if v := normalizeContact(values[f]); v != "" {
+				keys = append(keys, f+"="+v)
+			}
+		}
+		return keys
+	}
+}
+
+// ClientIP returns the request's remote address without the port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// peekBody reads up to 1MB of the body and puts it back for the handler.
+func peekBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Method == http.MethodGet {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func fieldValues(r *http.Request, body []byte, fields []string) map[string]string {
+	out := make(map[string]string, len(fields))
+	if strings.Contains(strings.ToLower(r.Header.Get("Content-Type")), "application/json") {
+		var payload map[string]any
+		if json.Unmarshal(body, &payload) == nil {
+			for _, f := range fields {
+				if s, ok := payload[f].(string); ok {
+					out[f] = s
+				}
+			}
+		}
+		return out
+	}
+	if r.PostForm != nil {
+		// an earlier middleware already consumed the body
+		for _, f := range fields {
+			out[f] = r.PostForm.Get(f)
+		}
+		return out
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return out
+	}
+	for _, f := range fields {
+		out[f] = form.Get(f)
+	}
+	return out
+}
+
+func normalizeContact(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" || strings.Contains(v, "@") {
+		return v
+	}
+	var digits []byte
+	for i := 0; i < len(v); i++ {
+		if v[i] >= '0' && v[i] <= '9' {
+			digits = append(digits, v[i])
+		}
+	}
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return string(digits)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string, retryAfter time.Duration) {
+	body := map[string]any{"error": msg}
+	if retryAfter > 0 {
+		secs := int(retryAfter.Round(time.Second) / time.Second)
+		if secs < 1 {
+			secs = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		body["retryAfter"] = secs
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
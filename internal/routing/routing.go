@@ -0,0 +1,347 @@
+// Package routing assigns leads and displayed contact details to agents or teams
+// using rules on listing type, location, property type and price.
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Agent is a person leads can be assigned to.
+type Agent struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+	// WhatsApp is the chat number when it differs from Phone.
+	WhatsApp string `json:"whatsapp,omitempty"`
+	// NestloID is the agent's user ID in Nestlo, sent as assigned_agent_id.
+	NestloID string `json:"nestloId,omitempty"`
+}
+
+// Team shares leads between its members in turn. When Phone or Email is set the
+// team's desk line is shown on listings instead of an individual member's.
+type Team struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Members  []string `json:"members"` // agent IDs
+	Phone    string   `json:"phone,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	WhatsApp string   `json:"whatsapp,omitempty"`
+}
+
+// Rule sends matching leads to an agent or a team. Empty conditions match anything,
+// so a rule with no conditions works as a catch-all at the end of the list.
+type Rule struct {
+	Name          string   `json:"name"`
+	ListingTypes  []string `json:"listingTypes,omitempty"` // rent, sale
+	Neighborhoods []string `json:"neighborhoods,omitempty"`
+	Cities        []string `json:"cities,omitempty"`
+	PropertyTypes []string `json:"propertyTypes,omitempty"`
+	PriceMin      float64  `json:"priceMin,omitempty"`
+	PriceMax      float64  `json:"priceMax,omitempty"`
+	Agent         string   `json:"agent,omitempty"`
+	Team          string   `json:"team,omitempty"`
+}
+
+// Config is the routing file: agents, teams and rules, tried in order.
+type Config struct {
+	Agents []Agent `json:"agents"`
+	Teams  []Team  `json:"teams"`
+	Rules  []Rule  `json:"rules"`
+}
+
+// Subject is what a lead or listing is routed on.
+type Subject struct {
+	PropertyID   string
+	ListingType  string
+	Neighborhood string
+	City         string
+	PropertyType string
+	Price        float64
+	// Address is searched for neighborhood and city names when those fields are empty.
+	Address string
+}
+
+// Assignment is the outcome of routing. Agent fields are empty when a team desk
+// line was chosen for display.
+type Assignment struct {
+	Rule      string
+	TeamID    string
+	TeamName  string
+	AgentID   string
+	AgentName string
+	NestloID  string
+	Phone     string
+	Email     string
+	WhatsApp  string
+}
+
+// Label describes the assignee for lead notes and logs.
+func (a Assignment) Label() string {
+	switch {
+	case a.AgentName != "" && a.TeamName != "":
+		return a.AgentName + ", " + a.TeamName
+	case a.AgentName != "":
+		return a.AgentName
+	default:
+		return a.TeamName
+	}
+}
+
+// NewRouterFromEnv reads rules from LEAD_ROUTING_PATH (default data/lead-routing.json).
+// Without the file no rule matches and callers fall back to their defaults.
+func NewRouterFromEnv() *Router {
+	return &Router{Path: config.Get().Stores.LeadRouting}
+}
+
+// Router applies the rules in a JSON file. The file is re-read when it changes.
+// Round-robin positions are kept in memory and restart from the first member.
+type Router struct {
+	Path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	config  *Config
+	next    map[string]int // team ID -> next member index
+}
+
+// Assign routes a lead, handing team leads to members in turn.
+func (r *Router) Assign(s Subject) (Assignment, bool) {
+	return r.route(s, true)
+}
+
+// Contact picks the phone and email to show for a listing. A team without a desk
+// line shows one member per listing, chosen by property ID so the page is stable.
+func (r *Router) Contact(s Subject) (Assignment, bool) {
+	return r.route(s, false)
+}
+
+func (r *Router) route(s Subject, rotate bool) (Assignment, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		log.Printf("routing: %v", err)
+		return Assignment{}, false
+	}
+	for _, rule := range cfg.Rules {
+		if !rule.Matches(s) {
+			continue
+		}
+		if a, ok := r.resolve(cfg, rule, s, rotate); ok {
+			return a, true
+		}
+		log.Printf("routing: rule %q points at an unknown agent or empty team", rule.Name)
+	}
+	return Assignment{}, false
+}
+
+// resolve turns a matched rule into an assignee. Caller holds r.mu.
+func (r *Router) resolve(cfg *Config, rule Rule, s Subject, rotate bool) (Assignment, bool) {
+	if rule.Agent != "" {
+		ag, ok := cfg.agent(rule.Agent)
+		if !ok {
+			return Assignment{}, false
+		}
+		return agentAssignment(rule.Name, Team{}, ag), true
+	}
+
+	team, ok := cfg.team(rule.Team)
+	if !ok {
+		return Assignment{}, false
+	}
+	var members []Agent
+	for _, id := range team.Members {
+		if ag, ok := cfg.agent(id); ok {
+			members = append(members, ag)
+		}
+	}
+
+	if !rotate && (team.Phone != "" || team.Email != "") {
+		a := Assignment{Rule: rule.Name, TeamID: team.ID, TeamName: team.Name, Phone: team.Phone, Email: team.Email, WhatsApp: team.WhatsApp}
+		if len(members) > 0 {
+			// fill whichever desk detail is missing from a member
+			m := members[stableIndex(s.PropertyID, len(members))]
+			a.Phone = firstNonEmpty(a.Phone, m.Phone)
+			a.Email = firstNonEmpty(a.Email, m.Email)
+			a.WhatsApp = firstNonEmpty(a.WhatsApp, m.WhatsApp)
+		}
+		return a, true
+	}
+	if len(members) == 0 {
+		return Assignment{}, false
+	}
+
+	var i int
+	if rotate {
+		if r.next == nil {
+			r.next = make(map[string]int)
+		}
+		i = r.next[team.ID] % len(members)
+		r.next[team.ID] = i + 1
+	} else {
+		i = stableIndex(s.PropertyID, len(members))
+	}
+	a := agentAssignment(rule.Name, team, members[i])
+	a.Phone = firstNonEmpty(a.Phone, team.Phone)
+	a.Email = firstNonEmpty(a.Email, team.Email)
+	a.WhatsApp = firstNonEmpty(a.WhatsApp, team.WhatsApp)
+	return a, true
+}
+
+// load returns the cached config, re-reading the file when its mtime changes.
+// Caller holds r.mu.
+func (r *Router) load() (*Config, error) {
+	info, err := os.Stat(r.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if r.config == nil {
+			log.Printf("routing: %s not found - using the default contact details", r.Path)
+			r.config = &Config{}
+		}
+		return r.config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.config != nil && info.ModTime().Equal(r.modTime) {
+		return r.config, nil
+	}
+
+	raw, err := os.ReadFile(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", r.Path, err)
+	}
+	r.config, r.modTime = &cfg, info.ModTime()
+	return r.config, nil
+}
+
+// Matches reports whether every condition set on the rule holds for s.
+func (rule Rule) Matches(s Subject) bool {
+	if len(rule.ListingTypes) > 0 && !containsFold(rule.ListingTypes, ListingType(s.ListingType), ListingType) {
+		return false
+	}
+	if len(rule.PropertyTypes) > 0 && !containsFold(rule.PropertyTypes, s.PropertyType, nil) {
+		return false
+	}
+	if len(rule.Neighborhoods) > 0 && !placeMatches(rule.Neighborhoods, s.Neighborhood, s.Address) {
+		return false
+	}
+	if len(rule.Cities) > 0 && !placeMatches(rule.Cities, s.City, s.Address) {
+		return false
+	}
+	if rule.PriceMin > 0 || rule.PriceMax > 0 {
+		if s.Price <= 0 {
+			return false
+		}
+		if rule.PriceMin > 0 && s.Price < rule.PriceMin {
+			return false
+		}
+		if rule.PriceMax > 0 && s.Price > rule.PriceMax {
+			return false
+		}
+	}
+	return true
+}
+
+// ListingType folds the listing type spellings used across Nestlo and the site
+// into "rent" or "sale".
+func ListingType(v string) string {
+	clean := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(v)), " ", "_")
+	switch clean {
+	case "rent", "rental", "listed_rental", "lease", "to-let", "to_let", "tolet", "tenant":
+		return "rent"
+	case "sale", "sell", "listed_sale", "for_sale", "buyer":
+		return "sale"
+	}
+	return clean
+}
+
+func (c *Config) agent(id string) (Agent, bool) {
+	for _, ag := range c.Agents {
+		if ag.ID == id {
+			return ag, true
+		}
+	}
+	return Agent{}, false
+}
+
+func (c *Config) team(id string) (Team, bool) {
+	for _, t := range c.Teams {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Team{}, false
+}
+
+func agentAssignment(rule string, team Team, ag Agent) Assignment {
+	return Assignment{
+		Rule:      rule,
+		TeamID:    team.ID,
+		TeamName:  team.Name,
+		AgentID:   ag.ID,
+		AgentName: ag.Name,
+		NestloID:  ag.NestloID,
+		Phone:     ag.Phone,
+		Email:     ag.Email,
+		WhatsApp:  ag.WhatsApp,
+	}
+}
+
+func containsFold(list []string, v string, norm func(string) string) bool {
+	for _, item := range list {
+		if norm != nil {
+			item = norm(item)
+		}
+		if strings.EqualFold(strings.TrimSpace(item), strings.TrimSpace(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeMatches compares against the structured field, or looks for the name in the
+// address when the listing has no such field.
+func placeMatches(names []string, field, address string) bool {
+	if strings.TrimSpace(field) != "" {
+		return containsFold(names, field, nil)
+	}
+	address = strings.ToLower(address)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && strings.Contains(address, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func stableIndex(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,169 @@
+package savedsearch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/api"
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/notify"
+)
+
+// digestMaxListings is how many new listings an email digest spells out.
+const digestMaxListings = 10
+
+// Runner periodically re-runs every saved search and sends a digest of listings
+// that have not been seen before.
+type Runner struct {
+	Store    Store
+	Notifier notify.Notifier
+	// Search runs a normalized query and returns up to max listings.
+	Search func(q url.Values, max int) ([]api.Property, error)
+	// SiteURL is the public base URL used for links in digests.
+	SiteURL    string
+	Interval   time.Duration
+	MaxResults int
+}
+
+// NewRunnerFromEnv wires a runner for the given store. SAVED_SEARCH_INTERVAL_MINUTES sets
+// the period (default 60) and PUBLIC_SITE_URL the base for links.
+func NewRunnerFromEnv(store Store) *Runner {
+	cfg := config.Get()
+	return &Runner{
+		Store:      store,
+		Notifier:   notify.NewFromEnv(),
+		Search:     func(q url.Values, max int) ([]api.Property, error) { return api.New().SearchAll(q, max) },
+		SiteURL:    cfg.SiteURL(),
+		Interval:   cfg.Alerts.SavedSearchInterval,
+		MaxResults: 200,
+	}
+}
+
+// Run checks all searches now and then on every tick until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	log.Printf("🔎 Saved searches: checking for new listings every %s", r.Interval)
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		r.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks every saved search a single time. Failures are logged and retried on
+// the next run; seen IDs are only updated after a digest has been handed off.
+func (r *Runner) RunOnce(ctx context.Context) {
+	for _, sr := range r.Store.All() {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := r.check(sr); err != nil {
+			log.Printf("saved search %s: %v", sr.ID, err)
+		}
+	}
+}
+
+func (r *Runner) check(sr Search) error {
+	q, err := url.ParseQuery(sr.Query)
+	if err != nil {
+		return fmt.Errorf("bad query: %w", err)
+	}
+	props, err := r.Search(q, r.MaxResults)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(sr.SeenIDs))
+	for _, id := range sr.SeenIDs {
+		seen[id] = struct{}{}
+	}
+	fresh := make([]api.Property, 0)
+	for _, p := range props {
+		if _, ok := seen[p.ID]; ok || !p.IsAvailable() {
+			continue
+		}
+		seen[p.ID] = struct{}{}
+		fresh = append(fresh, p)
+	}
+
+	now := time.Now()
+	if !sr.Primed || len(fresh) == 0 {
+		// The first run only records what already exists.
+		return r.Store.RecordRun(sr.ID, propertyIDs(fresh), false, now)
+	}
+	if err := r.Notifier.Send(r.digest(sr, fresh)); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return r.Store.RecordRun(sr.ID, propertyIDs(fresh), true, now)
+}
+
+func (r *Runner) digest(sr Search, fresh []api.Property) notify.Message {
+	searchURL := r.SiteURL + "/search?" + sr.Query
+	noun := "listings"
+	if len(fresh) == 1 {
+		noun = "listing"
+	}
+	headline := fmt.Sprintf("%d new %s for \"%s\"", len(fresh), noun, sr.Name)
+
+	if sr.Channel == notify.ChannelSMS {
+		return notify.Message{
+			Channel: notify.ChannelSMS,
+			To:      sr.To,
+			Text:    fmt.Sprintf("DhakaHome: %s. %s", headline, searchURL),
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(headline + ":\n\n")
+	for i, p := range fresh {
+		if i == digestMaxListings {
+			fmt.Fprintf(&b, "...and %d more.\n\n", len(fresh)-digestMaxListings)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", p.Title)
+		if line := listingSummary(p); line != "" {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+		fmt.Fprintf(&b, "  %s/properties/%s\n\n", r.SiteURL, url.PathEscape(p.ID))
+	}
+	fmt.Fprintf(&b, "See all results: %s\n", searchURL)
+	fmt.Fprintf(&b, "Manage your alerts: %s/saved-searches\n", r.SiteURL)
+
+	return notify.Message{
+		Channel: notify.ChannelEmail,
+		To:      sr.To,
+		Subject: "DhakaHome: " + headline,
+		Text:    b.String(),
+	}
+}
+
+func listingSummary(p api.Property) string {
+	parts := make([]string, 0, 3)
+	if p.Price > 0 {
+		parts = append(parts, "BDT "+strconv.FormatFloat(p.Price, 'f', 0, 64))
+	}
+	if p.Bedrooms > 0 {
+		parts = append(parts, fmt.Sprintf("%d bed", p.Bedrooms))
+	}
+	if p.Address != "" {
+		parts = append(parts, p.Address)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func propertyIDs(props []api.Property) []string {
+	ids := make([]string, 0, len(props))
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
@@ -0,0 +1,161 @@
+// Package savedsearch keeps users' named searches and alerts them when new listings match.
+package savedsearch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+// MaxSeen caps the remembered asset IDs per search; the oldest are forgotten first.
+const MaxSeen = 500
+
+var ErrNotFound = errors.New("savedsearch: search not found")
+
+// Search is a named, normalized query plus where to send new-listing alerts.
+type Search struct {
+	ID       string `json:"id"`
+	OwnerKey string `json:"ownerKey"`
+	Name     string `json:"name"`
+	// Query is the URL-encoded output of api.NormalizeSearchQuery.
+	Query   string `json:"query"`
+	Channel string `json:"channel"` // notify.ChannelEmail or notify.ChannelSMS
+	To      string `json:"to"`
+	// SeenIDs are asset IDs already matched, newest first. Primed is false until the
+	// first run has seeded them, so existing listings are never reported as new.
+	SeenIDs        []string  `json:"seenIds,omitempty"`
+	Primed         bool      `json:"primed"`
+	CreatedAt      time.Time `json:"createdAt"`
+	LastRunAt      time.Time `json:"lastRunAt,omitempty"`
+	LastNotifiedAt time.Time `json:"lastNotifiedAt,omitempty"`
+}
+
+// Store persists saved searches.
+type Store interface {
+	Create(s Search) (Search, error)
+	Get(id string) (Search, error)
+	ListByOwner(ownerKey string) []Search
+	All() []Search
+	Delete(ownerKey, id string) error
+	// RecordRun stores the result of an alert run: newly seen IDs go to the front.
+	RecordRun(id string, newIDs []string, notified bool, at time.Time) error
+}
+
+// NewFromEnv returns a file store at SAVED_SEARCH_PATH (default data/saved-searches.json).
+// Set SAVED_SEARCH_PATH=memory to keep searches in memory only.
+func NewFromEnv() Store {
+	return NewFileStore(config.Get().Stores.SavedSearches)
+}
+
+// FileStore keeps searches in memory and snapshots them to a JSON file on each write.
+type FileStore struct {
+	mu       sync.Mutex
+	file     *jsonstore.File
+	searches map[string]Search
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{searches: make(map[string]Search)}
+	s.file = jsonstore.Open("savedsearch", path, &s.searches)
+	return s
+}
+
+func (s *FileStore) Create(sr Search) (Search, error) {
+	if sr.OwnerKey == "" || sr.Name == "" || sr.To == "" {
+		return Search{}, errors.New("savedsearch: owner, name and recipient are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sr.ID = newID()
+	sr.CreatedAt = time.Now().UTC()
+	s.searches[sr.ID] = sr
+	return sr, s.save()
+}
+
+func (s *FileStore) Get(id string) (Search, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sr, ok := s.searches[id]
+	if !ok {
+		return Search{}, ErrNotFound
+	}
+	return sr, nil
+}
+
+func (s *FileStore) ListByOwner(ownerKey string) []Search {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Search, 0)
+	for _, sr := range s.searches {
+		if sr.OwnerKey == ownerKey {
+			out = append(out, sr)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) All() []Search {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Search, 0, len(s.searches))
+	for _, sr := range s.searches {
+		out = append(out, sr)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) Delete(ownerKey, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sr, ok := s.searches[id]
+	if !ok || sr.OwnerKey != ownerKey {
+		return ErrNotFound
+	}
+	delete(s.searches, id)
+	return s.save()
+}
+
+func (s *FileStore) RecordRun(id string, newIDs []string, notified bool, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sr, ok := s.searches[id]
+	if !ok {
+		// Deleted while the run was in flight.
+		return ErrNotFound
+	}
+	seen := make([]string, 0, len(newIDs)+len(sr.SeenIDs))
+	seen = append(seen, newIDs...)
+	seen = append(seen, sr.SeenIDs...)
+	if len(seen) > MaxSeen {
+		seen = seen[:MaxSeen]
+	}
+	sr.SeenIDs = seen
+	sr.Primed = true
+	sr.LastRunAt = at.UTC()
+	if notified {
+		sr.LastNotifiedAt = at.UTC()
+	}
+	s.searches[id] = sr
+	return s.save()
+}
+
+// save snapshots the searches. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.searches)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,185 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+const CookieName = "dh_session"
+
+// Session is the server-side state behind the dh_session cookie.
+type Session struct {
+	ID     string
+	UserID string
+	Name   string
+	Email  string
+	Phone  string
+	Token  string // Nestlo user JWT used for shortlist calls
+	// RecentlyViewed holds property IDs, newest first.
+	RecentlyViewed []string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	ttl      time.Duration
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultStore *Store
+)
+
+// store lazily builds the process-wide store so SESSION_TTL_HOURS from .env files is honoured.
+func store() *Store {
+	defaultOnce.Do(func() {
+		defaultStore = NewStore(config.Get().SessionTTL)
+	})
+	return defaultStore
+}
+
+func NewStore(ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Store{
+		sessions: make(map[string]*Session),
+		ttl:      ttl,
+	}
+}
+
+// TTL returns the default session lifetime.
+func TTL() time.Duration { return store().ttl }
+
+// Start creates a new session, stores it and sets the cookie on w.
+func Start(w http.ResponseWriter, r *http.Request, s Session) *Session {
+	return store().Start(w, r, s)
+}
+
+// FromRequest returns the live session referenced by the request cookie, if any.
+func FromRequest(r *http.Request) (*Session, bool) {
+	return store().FromRequest(r)
+}
+
+// Update applies fn to the live session behind the request cookie. It reports false when
+// there is no session.
+func Update(r *http.Request, fn func(*Session)) bool {
+	return store().Update(r, fn)
+}
+
+// Destroy removes the current session and expires the cookie.
+func Destroy(w http.ResponseWriter, r *http.Request) {
+	store().Destroy(w, r)
+}
+
+func (st *Store) Start(w http.ResponseWriter, r *http.Request, s Session) *Session {
+	now := time.Now()
+	s.ID = newID()
+	s.CreatedAt = now
+	if s.ExpiresAt.IsZero() || s.ExpiresAt.Before(now) {
+		s.ExpiresAt = now.Add(st.ttl)
+	}
+
+	st.mu.Lock()
+	st.sweep(now)
+	if old, ok := st.lookup(r); ok {
+		delete(st.sessions, old.ID)
+	}
+	stored := s
+	st.sessions[s.ID] = &stored
+	st.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    s.ID,
+		Path:     "/",
+		Expires:  s.ExpiresAt,
+		HttpOnly: true,
+		Secure:   IsSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return &s
+}
+
+func (st *Store) FromRequest(r *http.Request) (*Session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.lookup(r)
+	if !ok {
+		return nil, false
+	}
+	cp := *s
+	cp.RecentlyViewed = append([]string(nil), s.RecentlyViewed...)
+	return &cp, true
+}
+
+func (st *Store) Update(r *http.Request, fn func(*Session)) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.lookup(r)
+	if !ok {
+		return false
+	}
+	fn(s)
+	return true
+}
+
+func (st *Store) Destroy(w http.ResponseWriter, r *http.Request) {
+	st.mu.Lock()
+	if s, ok := st.lookup(r); ok {
+		delete(st.sessions, s.ID)
+	}
+	st.mu.Unlock()
+
+	Clear(w, r, CookieName)
+}
+
+// lookup resolves the cookie to a live session. Caller holds st.mu.
+func (st *Store) lookup(r *http.Request) (*Session, bool) {
+	c, err := r.Cookie(CookieName)
+	if err != nil || strings.TrimSpace(c.Value) == "" {
+		return nil, false
+	}
+	s, ok := st.sessions[c.Value]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(s.ExpiresAt) {
+		delete(st.sessions, s.ID)
+		return nil, false
+	}
+	return s, true
+}
+
+// sweep drops expired sessions. Caller holds st.mu.
+func (st *Store) sweep(now time.Time) {
+	for id, s := range st.sessions {
+		if now.After(s.ExpiresAt) {
+			delete(st.sessions, id)
+		}
+	}
+}
+
+// IsSecure reports whether the request arrived over HTTPS (directly or via a proxy).
+func IsSecure(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
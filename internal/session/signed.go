@@ -0,0 +1,101 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+var (
+	secretOnce sync.Once
+	secret     []byte
+)
+
+// signingKey returns COOKIE_SECRET, or a random per-process key when it is unset.
+// A random key means signed cookies do not survive restarts, which is fine locally.
+func signingKey() []byte {
+	secretOnce.Do(func() {
+		if v := config.Get().CookieSecret; v != "" {
+			secret = []byte(v)
+			return
+		}
+		log.Printf("session: COOKIE_SECRET not set - using a random key (signed cookies reset on restart)")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	})
+	return secret
+}
+
+// Sign returns value encoded and tagged with an HMAC so it can be handed to the browser.
+func Sign(value string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value))
+	return payload + "." + mac(payload)
+}
+
+// Unsign reverses Sign, rejecting tampered or malformed input.
+func Unsign(signed string) (string, bool) {
+	payload, tag, ok := strings.Cut(strings.TrimSpace(signed), ".")
+	if !ok || payload == "" || tag == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(tag), []byte(mac(payload))) {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+func mac(payload string) string {
+	h := hmac.New(sha256.New, signingKey())
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// SetSigned writes a signed, HttpOnly cookie readable with GetSigned.
+func SetSigned(w http.ResponseWriter, r *http.Request, name, value string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    Sign(value),
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Expires:  time.Now().Add(maxAge),
+		HttpOnly: true,
+		Secure:   IsSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// GetSigned returns the verified value of a cookie written by SetSigned.
+func GetSigned(r *http.Request, name string) (string, bool) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return Unsign(c.Value)
+}
+
+// Clear expires a cookie.
+func Clear(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   IsSecure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
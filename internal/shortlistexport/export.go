@@ -0,0 +1,375 @@
+// Package shortlistexport turns a shortlist into files people can take offline:
+// a CSV for spreadsheets and a printable PDF sheet with one block per property.
+package shortlistexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Row is one exported property, already resolved for display.
+type Row struct {
+	ID           string
+	Title        string
+	Address      string
+	Type         string
+	ListingType  string
+	Price        float64
+	Currency     string
+	Area         int
+	Bedrooms     int
+	Bathrooms    int
+	Parking      int
+	URL          string
+	Photo        string // absolute URL or site path such as /assets/...
+	ContactPhone string
+	ContactEmail string
+	Notes        string
+}
+
+// Sheet describes the whole export.
+type Sheet struct {
+	Title       string
+	GeneratedAt time.Time
+	Rows        []Row
+	// PublicDir resolves site paths like /assets/... to local files. Defaults to "public".
+	PublicDir string
+}
+
+// WriteCSV writes the rows as CSV with a header line.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	header := []string{"Title", "Price", "Currency", "Area (sqft)", "Bedrooms", "Bathrooms", "Parking", "Type", "Listing type", "Address", "Listing URL", "Contact phone", "Contact email", "Notes"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{
+			r.Title,
+			strconv.FormatFloat(r.Price, 'f', -1, 64),
+			r.Currency,
+			strconv.Itoa(r.Area),
+			strconv.Itoa(r.Bedrooms),
+			strconv.Itoa(r.Bathrooms),
+			strconv.Itoa(r.Parking),
+			r.Type,
+			r.ListingType,
+			r.Address,
+			r.URL,
+			r.ContactPhone,
+			r.ContactEmail,
+			r.Notes,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePDF renders an A4 sheet with a photo, key stats and contact details per property.
+func WritePDF(w io.Writer, sheet Sheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	publicDir := sheet.PublicDir
+	if publicDir == "" {
+		publicDir = "public"
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetTextColor(59, 59, 59)
+	pdf.CellFormat(0, 10, tr(sheet.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(121, 121, 121)
+	generated := sheet.GeneratedAt
+	if generated.IsZero() {
+		generated = time.Now()
+	}
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("%d properties - generated %s", len(sheet.Rows), generated.Format("2 Jan 2006"))), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	const blockHeight = 58.0
+	const photoW, photoH = 70.0, 50.0
+	photos := make(map[string]string)
+	remote := fetchPhotos(sheet.Rows)
+	for _, r := range sheet.Rows {
+		_, pageH := pdf.GetPageSize()
+		_, _, _, bottom := pdf.GetMargins()
+		if pdf.GetY()+blockHeight > pageH-bottom {
+			pdf.AddPage()
+		}
+		top := pdf.GetY()
+		left, _, right, _ := pdf.GetMargins()
+		pageW, _ := pdf.GetPageSize()
+
+		if name, ok := registerPhoto(pdf, r.Photo, publicDir, remote, photos); ok {
+			pdf.ImageOptions(name, left, top, photoW, photoH, false, fpdf.ImageOptions{}, 0, "")
+		} else {
+			pdf.SetFillColor(242, 242, 242)
+			pdf.Rect(left, top, photoW, photoH, "F")
+		}
+
+		x := left + photoW + 6
+		textW := pageW - right - x
+		pdf.SetXY(x, top)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetTextColor(59, 59, 59)
+		pdf.MultiCell(textW, 6, tr(r.Title), "", "L", false)
+
+		pdf.SetX(x)
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.SetTextColor(244, 67, 53)
+		pdf.CellFormat(textW, 7, tr(priceLabel(r)), "", 1, "L", false, 0, "")
+
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(65, 65, 65)
+		for _, line := range []string{
+			statsLine(r),
+			r.Address,
+			contactLine(r),
+			r.URL,
+		} {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			pdf.SetX(x)
+			pdf.MultiCell(textW, 5, tr(line), "", "L", false)
+		}
+		if r.Notes != "" {
+			pdf.SetX(x)
+			pdf.SetFont("Helvetica", "I", 9)
+			pdf.SetTextColor(121, 121, 121)
+			pdf.MultiCell(textW, 5, tr("Notes: "+r.Notes), "", "L", false)
+		}
+
+		y := pdf.GetY()
+		if y < top+photoH {
+			y = top + photoH
+		}
+		pdf.SetDrawColor(220, 220, 220)
+		pdf.Line(left, y+3, pageW-right, y+3)
+		pdf.SetY(y + 6)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func priceLabel(r Row) string {
+	currency := strings.ToUpper(strings.TrimSpace(r.Currency))
+	if currency == "" || currency == "৳" {
+		// The built-in PDF fonts have no taka sign.
+		currency = "BDT"
+	}
+	label := currency + " " + groupDigits(int64(r.Price))
+	if strings.Contains(strings.ToLower(r.ListingType), "rent") {
+		label += " / month"
+	}
+	return label
+}
+
+func statsLine(r Row) string {
+	parts := make([]string, 0, 4)
+	if r.Area > 0 {
+		parts = append(parts, fmt.Sprintf("%s sqft", groupDigits(int64(r.Area))))
+	}
+	if r.Bedrooms > 0 {
+		parts = append(parts, fmt.Sprintf("%d bed", r.Bedrooms))
+	}
+	if r.Bathrooms > 0 {
+		parts = append(parts, fmt.Sprintf("%d bath", r.Bathrooms))
+	}
+	if r.Parking > 0 {
+		parts = append(parts, fmt.Sprintf("%d parking", r.Parking))
+	}
+	if r.Type != "" {
+		parts = append(parts, r.Type)
+	}
+	return strings.Join(parts, "  |  ")
+}
+
+func contactLine(r Row) string {
+	parts := make([]string, 0, 2)
+	if r.ContactPhone != "" {
+		parts = append(parts, r.ContactPhone)
+	}
+	if r.ContactEmail != "" {
+		parts = append(parts, r.ContactEmail)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Contact: " + strings.Join(parts, " / ")
+}
+
+// groupDigits formats n with thousands separators (1,250,000).
+func groupDigits(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if len(s) <= 3 {
+		return s
+	}
+	var out strings.Builder
+	lead := len(s) % 3
+	if lead > 0 {
+		out.WriteString(s[:lead])
+	}
+	for i := lead; i < len(s); i += 3 {
+		if out.Len() > 0 {
+			out.WriteByte(',')
+		}
+		out.WriteString(s[i : i+3])
+	}
+	return out.String()
+}
+
+// Remote photos are fetched in parallel before the PDF is laid out, bounded in number and
+// in total time so a slow image host cannot run the export past its route timeout.
+const (
+	maxRemotePhotos   = 40
+	photoConcurrency  = 6
+	photoFetchBudget  = 15 * time.Second
+	photoFetchTimeout = 5 * time.Second
+)
+
+var photoClient = &http.Client{Timeout: photoFetchTimeout}
+
+// fetchPhotos downloads the rows' remote photos, keyed by URL. Photos past the cap or
+// not back within the budget are left out and the sheet shows a placeholder.
+func fetchPhotos(rows []Row) map[string][]byte {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		src := strings.TrimSpace(r.Photo)
+		if seen[src] || !(strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")) {
+			continue
+		}
+		seen[src] = true
+		urls = append(urls, src)
+	}
+	if len(urls) > maxRemotePhotos {
+		log.Printf("shortlist export: fetching %d of %d photos", maxRemotePhotos, len(urls))
+		urls = urls[:maxRemotePhotos]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), photoFetchBudget)
+	defer cancel()
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = make(map[string][]byte, len(urls))
+		sem = make(chan struct{}, photoConcurrency)
+	)
+	for _, src := range urls {
+		wg.Add(1)
+		go func(src string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			raw, err := fetchPhoto(ctx, src)
+			if err != nil {
+				log.Printf("shortlist export: photo %s: %v", src, err)
+				return
+			}
+			mu.Lock()
+			out[src] = raw
+			mu.Unlock()
+		}(src)
+	}
+	wg.Wait()
+	return out
+}
+
+// registerPhoto loads a JPEG or PNG into the document once per source: remote photos from
+// those fetchPhotos returned, site paths from publicDir. Missing or unsupported images are
+// skipped and remembered as such.
+func registerPhoto(pdf *fpdf.Fpdf, src, publicDir string, remote map[string][]byte, seen map[string]string) (string, bool) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return "", false
+	}
+	if name, ok := seen[src]; ok {
+		return name, name != ""
+	}
+	seen[src] = ""
+
+	var raw []byte
+	var err error
+	switch {
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		var ok bool
+		if raw, ok = remote[src]; !ok {
+			return "", false
+		}
+	case strings.HasPrefix(src, "/"):
+		clean := filepath.Clean(strings.TrimPrefix(src, "/"))
+		if strings.HasPrefix(clean, "..") {
+			return "", false
+		}
+		raw, err = os.ReadFile(filepath.Join(publicDir, clean))
+	default:
+		return "", false
+	}
+	if err != nil {
+		log.Printf("shortlist export: photo %s: %v", src, err)
+		return "", false
+	}
+
+	var imageType string
+	switch http.DetectContentType(raw) {
+	case "image/jpeg":
+		imageType = "JPG"
+	case "image/png":
+		imageType = "PNG"
+	default:
+		return "", false
+	}
+
+	name := fmt.Sprintf("photo-%d", len(seen))
+	pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(raw))
+	if pdf.Err() {
+		log.Printf("shortlist export: photo %s: %v", src, pdf.Error())
+		pdf.ClearError()
+		return "", false
+	}
+	seen[src] = name
+	return name, true
+}
+
+func fetchPhoto(ctx context.Context, src string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := photoClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 5<<20))
+}
@@ -0,0 +1,100 @@
+package shortlistmeta
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+// Meta is a user's private annotation on one shortlisted property.
+type Meta struct {
+	Notes     string    `json:"notes,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Rating    int       `json:"rating,omitempty"` // 1-5, 0 = unrated
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (m Meta) IsZero() bool {
+	return m.Notes == "" && len(m.Tags) == 0 && m.Rating == 0
+}
+
+// Store persists Meta per user and asset.
+type Store interface {
+	All(userKey string) map[string]Meta
+	Get(userKey, assetID string) (Meta, bool)
+	Put(userKey, assetID string, m Meta) error
+	Delete(userKey, assetID string) error
+}
+
+// NewFromEnv returns a file store at SHORTLIST_META_PATH (default data/shortlist-meta.json).
+// Set SHORTLIST_META_PATH=memory to keep annotations in memory only.
+func NewFromEnv() Store {
+	return NewFileStore(config.Get().Stores.ShortlistMeta)
+}
+
+// FileStore keeps everything in memory and snapshots it to a JSON file on each write.
+// With an empty path it is purely in-memory.
+type FileStore struct {
+	mu   sync.Mutex
+	file *jsonstore.File
+	data map[string]map[string]Meta
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{data: make(map[string]map[string]Meta)}
+	s.file = jsonstore.Open("shortlistmeta", path, &s.data)
+	return s
+}
+
+func (s *FileStore) All(userKey string) map[string]Meta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Meta, len(s.data[userKey]))
+	for id, m := range s.data[userKey] {
+		out[id] = m
+	}
+	return out
+}
+
+func (s *FileStore) Get(userKey, assetID string) (Meta, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.data[userKey][assetID]
+	return m, ok
+}
+
+func (s *FileStore) Put(userKey, assetID string, m Meta) error {
+	if userKey == "" || assetID == "" {
+		return errors.New("shortlistmeta: user and asset are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.IsZero() {
+		delete(s.data[userKey], assetID)
+		return s.save()
+	}
+	if s.data[userKey] == nil {
+		s.data[userKey] = make(map[string]Meta)
+	}
+	m.UpdatedAt = time.Now().UTC()
+	s.data[userKey][assetID] = m
+	return s.save()
+}
+
+func (s *FileStore) Delete(userKey, assetID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[userKey][assetID]; !ok {
+		return nil
+	}
+	delete(s.data[userKey], assetID)
+	return s.save()
+}
+
+// save snapshots the metadata. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.data)
+}
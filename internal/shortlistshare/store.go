@@ -0,0 +1,200 @@
+package shortlistshare
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+var (
+	ErrNotFound = errors.New("shortlistshare: share not found")
+	ErrRevoked  = errors.New("shortlistshare: share revoked")
+	ErrExpired  = errors.New("shortlistshare: share expired")
+)
+
+// Share is a read-only copy of one of a user's shortlists. Items are seeded from Nestlo
+// when the share is created, follow the owner's changes made on this site, and are
+// re-read from Nestlo whenever the owner opens the share or their list of shares.
+type Share struct {
+	ID          string    `json:"id"`
+	OwnerKey    string    `json:"ownerKey"`
+	OwnerName   string    `json:"ownerName,omitempty"`
+	ShortlistID string    `json:"shortlistId"`
+	Name        string    `json:"name"`
+	AssetIDs    []string  `json:"assetIds"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	ExpiresAt   time.Time `json:"expiresAt,omitempty"`
+	RevokedAt   time.Time `json:"revokedAt,omitempty"`
+}
+
+// Active reports whether the share can still be opened at now.
+func (s Share) Active(now time.Time) error {
+	if !s.RevokedAt.IsZero() {
+		return ErrRevoked
+	}
+	if !s.ExpiresAt.IsZero() && now.After(s.ExpiresAt) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// Store persists shares.
+type Store interface {
+	Create(s Share) (Share, error)
+	Get(id string) (Share, error)
+	ListByOwner(ownerKey, shortlistID string) []Share
+	Revoke(ownerKey, id string) error
+	// Refresh replaces the items of every live share of the owner's list.
+	Refresh(ownerKey, shortlistID string, assetIDs []string)
+	// SyncOwnerItem mirrors an owner's add/remove onto every live share of that list.
+	SyncOwnerItem(ownerKey, shortlistID, assetID string, present bool)
+}
+
+// NewFromEnv returns a file store at SHORTLIST_SHARE_PATH (default data/shortlist-shares.json).
+// Set SHORTLIST_SHARE_PATH=memory to keep shares in memory only.
+func NewFromEnv() Store {
+	return NewFileStore(config.Get().Stores.ShortlistShares)
+}
+
+// FileStore keeps shares in memory and snapshots them to a JSON file on each write.
+type FileStore struct {
+	mu     sync.Mutex
+	file   *jsonstore.File
+	shares map[string]Share
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{shares: make(map[string]Share)}
+	s.file = jsonstore.Open("shortlistshare", path, &s.shares)
+	return s
+}
+
+func (s *FileStore) Create(sh Share) (Share, error) {
+	if sh.OwnerKey == "" || sh.ShortlistID == "" {
+		return Share{}, errors.New("shortlistshare: owner and shortlist are required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh.ID = newID()
+	now := time.Now().UTC()
+	sh.CreatedAt = now
+	sh.UpdatedAt = now
+	s.shares[sh.ID] = sh
+	return sh, s.save()
+}
+
+func (s *FileStore) Get(id string) (Share, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, ok := s.shares[id]
+	if !ok {
+		return Share{}, ErrNotFound
+	}
+	return sh, nil
+}
+
+func (s *FileStore) ListByOwner(ownerKey, shortlistID string) []Share {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Share, 0)
+	for _, sh := range s.shares {
+		if sh.OwnerKey != ownerKey || (shortlistID != "" && sh.ShortlistID != shortlistID) {
+			continue
+		}
+		out = append(out, sh)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *FileStore) Revoke(ownerKey, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sh, ok := s.shares[id]
+	if !ok || sh.OwnerKey != ownerKey {
+		return ErrNotFound
+	}
+	if sh.RevokedAt.IsZero() {
+		sh.RevokedAt = time.Now().UTC()
+		s.shares[id] = sh
+	}
+	return s.save()
+}
+
+func (s *FileStore) Refresh(ownerKey, shortlistID string, assetIDs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	changed := false
+	for id, sh := range s.shares {
+		if sh.OwnerKey != ownerKey || sh.ShortlistID != shortlistID || sh.Active(now) != nil || slices.Equal(sh.AssetIDs, assetIDs) {
+			continue
+		}
+		sh.AssetIDs = slices.Clone(assetIDs)
+		sh.UpdatedAt = now.UTC()
+		s.shares[id] = sh
+		changed = true
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			log.Printf("shortlistshare: refresh %s: %v", shortlistID, err)
+		}
+	}
+}
+
+func (s *FileStore) SyncOwnerItem(ownerKey, shortlistID, assetID string, present bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	changed := false
+	for id, sh := range s.shares {
+		if sh.OwnerKey != ownerKey || sh.ShortlistID != shortlistID || sh.Active(now) != nil {
+			continue
+		}
+		s.shares[id] = setItem(sh, assetID, present)
+		changed = true
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			log.Printf("shortlistshare: sync %s: %v", assetID, err)
+		}
+	}
+}
+
+// setItem returns sh with assetID added (newest first) or removed.
+func setItem(sh Share, assetID string, present bool) Share {
+	kept := make([]string, 0, len(sh.AssetIDs)+1)
+	if present {
+		kept = append(kept, assetID)
+	}
+	for _, id := range sh.AssetIDs {
+		if id != assetID {
+			kept = append(kept, id)
+		}
+	}
+	sh.AssetIDs = kept
+	sh.UpdatedAt = time.Now().UTC()
+	return sh
+}
+
+// save snapshots the shares. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.shares)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
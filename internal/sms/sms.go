@@ -0,0 +1,121 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Sender delivers a text message to a single phone number (E.164, e.g. +8801712345678).
+type Sender interface {
+	Send(to, message string) error
+}
+
+// NewFromEnv picks a sender based on SMS_PROVIDER (console, file, http).
+// The default is the console sender so local runs never hit a gateway.
+func NewFromEnv() Sender {
+	cfg := config.Get().SMS
+	switch cfg.Provider {
+	case "file":
+		log.Printf("📱 SMS: writing messages to %s", cfg.OutboxPath)
+		return &FileSender{Path: cfg.OutboxPath}
+	case "http":
+		if cfg.GatewayURL == "" {
+			log.Printf("SMS: SMS_PROVIDER=http but SMS_GATEWAY_URL is empty - using console sender")
+			return ConsoleSender{}
+		}
+		return &HTTPSender{
+			URL:      cfg.GatewayURL,
+			APIKey:   cfg.APIKey,
+			SenderID: cfg.SenderID,
+			HC:       &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		return ConsoleSender{}
+	}
+}
+
+// ConsoleSender logs messages instead of sending them. Useful for local development.
+type ConsoleSender struct{}
+
+func (ConsoleSender) Send(to, message string) error {
+	log.Printf("📱 SMS to %s: %s", to, message)
+	return nil
+}
+
+// FileSender appends messages to a local outbox file so developers can read OTPs without a gateway.
+type FileSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileSender) Send(to, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return fmt.Errorf("sms outbox: %w", err)
+	}
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("sms outbox: %w", err)
+	}
+	defer f.Close()
+
+	line := fmt.Sprintf("%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), to, strings.ReplaceAll(message, "\n", " "))
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("sms outbox: %w", err)
+	}
+	return nil
+}
+
+// HTTPSender posts messages to a JSON SMS gateway.
+// Most Bangladeshi gateways accept {api_key, senderid, number, message}; adjust via a proxy if yours differs.
+type HTTPSender struct {
+	URL      string
+	APIKey   string
+	SenderID string
+	HC       *http.Client
+}
+
+func (s *HTTPSender) Send(to, message string) error {
+	body, _ := json.Marshal(map[string]string{
+		"api_key":  s.APIKey,
+		"senderid": s.SenderID,
+		"number":   strings.TrimPrefix(to, "+"),
+		"message":  message,
+	})
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	hc := s.HC
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 2048))
+		return fmt.Errorf("sms gateway: %s %s", res.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Package spam screens public form submissions: captcha verification, a content score
+// and a quarantine for anything that looks automated.
+package spam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// ErrCaptchaMissing is returned when a verifier is configured but the form sent no token.
+var ErrCaptchaMissing = errors.New("spam: captcha token missing")
+
+// Verifier checks a captcha response token. ok=false means the challenge failed;
+// err means the provider could not be asked.
+type Verifier interface {
+	Verify(token, remoteIP string) (ok bool, err error)
+}
+
+// Widget is what a form needs to render the challenge.
+type Widget struct {
+	Provider  string // turnstile, hcaptcha, recaptcha, fake or empty when disabled
+	SiteKey   string
+	ScriptURL string
+	Class     string // container class the provider script looks for
+}
+
+// Enabled reports whether forms should render a challenge.
+func (w Widget) Enabled() bool { return w.Provider != "" }
+
+type provider struct {
+	verifyURL string
+	scriptURL string
+	class     string
+}
+
+var providers = map[string]provider{
+	"turnstile": {"https://challenges.cloudflare.com/turnstile/v0/siteverify", "https://challenges.cloudflare.com/turnstile/v0/api.js", "cf-turnstile"},
+	"hcaptcha":  {"https://api.hcaptcha.com/siteverify", "https://js.hcaptcha.com/1/api.js", "h-captcha"},
+	"recaptcha": {"https://www.google.com/recaptcha/api/siteverify", "https://www.google.com/recaptcha/api.js", "g-recaptcha"},
+}
+
+// NewVerifierFromEnv picks a verifier from CAPTCHA_PROVIDER (turnstile, hcaptcha, recaptcha,
+// fake). Empty disables captcha; the other spam checks still apply. A real provider
+// without CAPTCHA_SECRET_KEY also disables it, with a warning; config refuses that
+// outside local development.
+func NewVerifierFromEnv() (Verifier, Widget) {
+	cfg := config.Get().Captcha
+	name := cfg.Provider
+	switch name {
+	case "":
+		return nil, Widget{}
+	case "fake":
+		log.Printf("🤖 Captcha: fake verifier (accepts any token except %q)", FakeRejectToken)
+		return Fake{}, Widget{Provider: "fake"}
+	}
+
+	p, ok := providers[name]
+	secret := cfg.SecretKey
+	if !ok || secret == "" {
+		log.Printf("Captcha: CAPTCHA_PROVIDER=%s is unknown or CAPTCHA_SECRET_KEY is empty - captcha disabled", name)
+		return nil, Widget{}
+	}
+	return &SiteVerify{
+		URL:      p.verifyURL,
+		Secret:   secret,
+		MinScore: cfg.MinScore,
+		HC:       &http.Client{Timeout: 5 * time.Second},
+	}, Widget{
+		Provider:  name,
+		SiteKey:   cfg.SiteKey,
+		ScriptURL: p.scriptURL,
+		Class:     p.class,
+	}
+}
+
+// SiteVerify implements the siteverify protocol shared by Turnstile, hCaptcha and reCAPTCHA:
+// a form POST of secret/response/remoteip answered with {"success": bool, "score": float}.
+type SiteVerify struct {
+	URL    string
+	Secret string
+	// MinScore applies to providers that return a score (reCAPTCHA v3, hCaptcha Enterprise).
+	MinScore float64
+	HC       *http.Client
+}
+
+func (v *SiteVerify) Verify(token, remoteIP string) (bool, error) {
+	if strings.TrimSpace(token) == "" {
+		return false, ErrCaptchaMissing
+	}
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	hc := v.HC
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	res, err := hc.PostForm(v.URL, form)
+	if err != nil {
+		return false, fmt.Errorf("captcha verify: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha verify: %s", res.Status)
+	}
+
+	var out struct {
+		Success bool     `json:"success"`
+		Score   *float64 `json:"score"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("captcha verify: %w", err)
+	}
+	if !out.Success {
+		return false, nil
+	}
+	if out.Score != nil && v.MinScore > 0 && *out.Score < v.MinScore {
+		return false, nil
+	}
+	return true, nil
+}
+
+// FakeRejectToken is the token the fake verifier refuses, for testing the failure path.
+const FakeRejectToken = "fail"
+
+// Fake accepts any non-empty token except FakeRejectToken. For local development only.
+type Fake struct{}
+
+func (Fake) Verify(token, _ string) (bool, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return false, ErrCaptchaMissing
+	}
+	return token != FakeRejectToken, nil
+}
@@ -0,0 +1,63 @@
+package spam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Entry is a submission held back as suspected spam, kept for manual review.
+type Entry struct {
+	At       time.Time       `json:"at"`
+	Form     string          `json:"form"`
+	RemoteIP string          `json:"remoteIp,omitempty"`
+	Result   Result          `json:"result"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// Quarantine stores suspected spam instead of delivering it.
+type Quarantine interface {
+	Put(e Entry) error
+}
+
+// NewQuarantineFromEnv appends to LEAD_QUARANTINE_PATH (default data/lead-quarantine.jsonl).
+func NewQuarantineFromEnv() Quarantine {
+	return &FileQuarantine{Path: config.Get().Stores.LeadQuarantine}
+}
+
+// FileQuarantine appends entries as JSON lines; review with any JSON tool.
+type FileQuarantine struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (q *FileQuarantine) Put(e Entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("spam quarantine: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(q.Path), 0o755); err != nil {
+		return fmt.Errorf("spam quarantine: %w", err)
+	}
+	f, err := os.OpenFile(q.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("spam quarantine: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("spam quarantine: %w", err)
+	}
+	return nil
+}
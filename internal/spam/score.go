@@ -0,0 +1,122 @@
+package spam
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Result is a spam verdict. Higher scores are more suspicious; Reasons explain each point.
+type Result struct {
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Add raises the score with a reason.
+func (r *Result) Add(points int, reason string) {
+	r.Score += points
+	r.Reasons = append(r.Reasons, reason)
+}
+
+var (
+	linkRegex     = regexp.MustCompile(`(?i)(https?://|www\.|\[url|<a\s)`)
+	shortenerHost = regexp.MustCompile(`(?i)\b(bit\.ly|tinyurl\.com|t\.co|goo\.gl|ow\.ly|is\.gd|cutt\.ly)\b`)
+	spamWords     = []string{
+		"casino", "viagra", "cialis", "crypto", "bitcoin", "forex", "betting", "porn",
+		"seo service", "backlink", "guest post", "rank your website", "web traffic",
+		"loan offer", "payday", "earn money", "work from home", "click here", "unsubscribe",
+	}
+)
+
+// ScoreContent rates free-text fields of a form. Bangla text is never penalised; the
+// checks target link drops, keyword stuffing and bot-shaped input.
+func ScoreContent(name, email, message string) Result {
+	var r Result
+	lowerMsg := strings.ToLower(message)
+
+	if n := len(linkRegex.FindAllString(message, -1)); n > 0 {
+		points := 15 * n
+		if points > 45 {
+			points = 45
+		}
+		r.Add(points, "links in message")
+	}
+	if shortenerHost.MatchString(message) {
+		r.Add(20, "link shortener")
+	}
+	if linkRegex.MatchString(name) {
+		r.Add(40, "link in name")
+	}
+
+	hits := 0
+	for _, w := range spamWords {
+		if strings.Contains(lowerMsg, w) {
+			hits++
+		}
+	}
+	if hits > 0 {
+		points := 20 * hits
+		if points > 60 {
+			points = 60
+		}
+		r.Add(points, "spam keywords")
+	}
+
+	if longestRun(message) >= 8 {
+		r.Add(10, "repeated characters")
+	}
+	if shouting(message) {
+		r.Add(10, "all caps")
+	}
+	if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(message)) && name != "" {
+		r.Add(20, "message repeats name")
+	}
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) > 20 && digitShare(local) > 0.5 {
+		r.Add(15, "generated-looking email")
+	}
+	return r
+}
+
+// shouting reports whether a long message has almost no lower-case Latin letters.
+func shouting(s string) bool {
+	upper, lower := 0, 0
+	for _, c := range s {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+	return upper >= 20 && lower*10 < upper
+}
+
+// longestRun is the length of the longest run of one repeated character ("!!!!!!!!").
+func longestRun(s string) int {
+	best, run := 0, 0
+	var prev rune = -1
+	for _, c := range s {
+		if c == prev {
+			run++
+		} else {
+			prev, run = c, 1
+		}
+		if run > best {
+			best = run
+		}
+	}
+	return best
+}
+
+func digitShare(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	digits := 0
+	for _, c := range s {
+		if unicode.IsDigit(c) {
+			digits++
+		}
+	}
+	return float64(digits) / float64(len([]rune(s)))
+}
@@ -0,0 +1,254 @@
+// Package viewing books property viewings: agent availability, bookings and the
+// calendar invites sent to visitors.
+package viewing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Asia/Dhaka must resolve on hosts without zoneinfo
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+)
+
+// Slot is one bookable viewing time with a specific agent.
+type Slot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	AgentID   string    `json:"agentId"`
+	AgentName string    `json:"agentName,omitempty"`
+}
+
+// Availability lists open viewing slots for a property between from and to.
+// Implementations do not know about bookings; callers remove taken slots.
+type Availability interface {
+	Slots(assetID string, from, to time.Time) ([]Slot, error)
+}
+
+// Schedule is the file format for FileAvailability.
+type Schedule struct {
+	Timezone string `json:"timezone"` // IANA name, default Asia/Dhaka
+	// SlotMinutes is the length of each viewing (default 45).
+	SlotMinutes int `json:"slotMinutes"`
+	// NoticeHours is how far ahead a slot must be to be bookable (default 12).
+	NoticeHours int `json:"noticeHours"`
+	// DaysAhead limits how far into the future slots are offered (default 14).
+	DaysAhead int             `json:"daysAhead"`
+	Agents    []AgentSchedule `json:"agents"`
+}
+
+// AgentSchedule is one agent's weekly hours.
+type AgentSchedule struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	// Weekly maps a lower-case weekday ("sat", "sun", ...) to "HH:MM-HH:MM" ranges.
+	Weekly map[string][]string `json:"weekly"`
+	// Closed lists YYYY-MM-DD dates the agent is unavailable (holidays, leave).
+	Closed []string `json:"closed,omitempty"`
+	// Properties restricts the agent to these asset IDs; empty means every property.
+	Properties []string `json:"properties,omitempty"`
+}
+
+// DefaultSchedule is used when no availability file exists: one office calendar,
+// Saturday to Thursday 10:00-18:00 Dhaka time.
+func DefaultSchedule() Schedule {
+	hours := []string{"10:00-13:00", "14:00-18:00"}
+	return Schedule{
+		Timezone:    "Asia/Dhaka",
+		SlotMinutes: 45,
+		NoticeHours: 12,
+		DaysAhead:   14,
+		Agents: []AgentSchedule{{
+			ID:   "office",
+			Name: "DhakaHome team",
+			Weekly: map[string][]string{
+				"sat": hours, "sun": hours, "mon": hours, "tue": hours, "wed": hours, "thu": hours,
+			},
+		}},
+	}
+}
+
+// NewAvailabilityFromEnv reads VIEWING_AVAILABILITY_PATH (default data/viewing-availability.json),
+// falling back to DefaultSchedule when the file does not exist.
+func NewAvailabilityFromEnv() *FileAvailability {
+	return &FileAvailability{Path: config.Get().Stores.ViewingAvailability}
+}
+
+// FileAvailability reads a Schedule from a JSON file. The file is re-read when it
+// changes, so agents' hours can be edited without a restart.
+type FileAvailability struct {
+	Path string
+
+	mu       sync.Mutex
+	modTime  time.Time
+	schedule *Schedule
+}
+
+// Schedule returns the current schedule.
+func (a *FileAvailability) Schedule() (Schedule, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		if a.schedule == nil {
+			log.Printf("viewing: %s not found - using the default office hours", a.Path)
+			def := DefaultSchedule()
+			a.schedule = &def
+		}
+		return *a.schedule, nil
+	}
+	if err != nil {
+		return Schedule{}, fmt.Errorf("viewing availability: %w", err)
+	}
+	if a.schedule != nil && info.ModTime().Equal(a.modTime) {
+		return *a.schedule, nil
+	}
+
+	raw, err := os.ReadFile(a.Path)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("viewing availability: %w", err)
+	}
+	var s Schedule
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return Schedule{}, fmt.Errorf("viewing availability %s: %w", a.Path, err)
+	}
+	a.schedule, a.modTime = &s, info.ModTime()
+	return s, nil
+}
+
+// Agent looks up an agent by ID.
+func (a *FileAvailability) Agent(id string) (AgentSchedule, bool) {
+	s, err := a.Schedule()
+	if err != nil {
+		return AgentSchedule{}, false
+	}
+	for _, ag := range s.Agents {
+		if ag.ID == id {
+			return ag, true
+		}
+	}
+	return AgentSchedule{}, false
+}
+
+func (a *FileAvailability) Slots(assetID string, from, to time.Time) ([]Slot, error) {
+	s, err := a.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	return s.Slots(assetID, from, to)
+}
+
+// Slots expands the weekly hours into slots between from and to, honouring the notice
+// period and booking horizon relative to from.
+func (s Schedule) Slots(assetID string, from, to time.Time) ([]Slot, error) {
+	loc, err := time.LoadLocation(firstNonEmpty(s.Timezone, "Asia/Dhaka"))
+	if err != nil {
+		return nil, fmt.Errorf("viewing availability: timezone: %w", err)
+	}
+	length := time.Duration(orDefault(s.SlotMinutes, 45)) * time.Minute
+	earliest := from.Add(time.Duration(orDefault(s.NoticeHours, 12)) * time.Hour)
+	if horizon := from.AddDate(0, 0, orDefault(s.DaysAhead, 14)); to.After(horizon) {
+		to = horizon
+	}
+
+	var out []Slot
+	for _, ag := range s.Agents {
+		if !servesProperty(ag, assetID) {
+			continue
+		}
+		closed := make(map[string]bool, len(ag.Closed))
+		for _, d := range ag.Closed {
+			closed[strings.TrimSpace(d)] = true
+		}
+		start := from.In(loc)
+		for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+			if closed[day.Format("2006-01-02")] {
+				continue
+			}
+			for _, rng := range ag.Weekly[weekdayKey(day.Weekday())] {
+				open, close, err := parseRange(day, rng)
+				if err != nil {
+					return nil, fmt.Errorf("viewing availability: agent %s: %w", ag.ID, err)
+				}
+				for t := open; !t.Add(length).After(close); t = t.Add(length) {
+					if t.Before(earliest) || !t.Before(to) {
+						continue
+					}
+					out = append(out, Slot{Start: t, End: t.Add(length), AgentID: ag.ID, AgentName: ag.Name})
+				}
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].AgentID < out[j].AgentID
+	})
+	return out, nil
+}
+
+func servesProperty(ag AgentSchedule, assetID string) bool {
+	if len(ag.Properties) == 0 {
+		return true
+	}
+	for _, id := range ag.Properties {
+		if id == assetID || id == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRange(day time.Time, rng string) (time.Time, time.Time, error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(rng), "-")
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("bad range %q, want HH:MM-HH:MM", rng)
+	}
+	open, err := clockOn(day, a)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	close, err := clockOn(day, b)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return open, close, nil
+}
+
+func clockOn(day time.Time, hhmm string) (time.Time, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(hhmm))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad time %q, want HH:MM", hhmm)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
+
+func weekdayKey(d time.Weekday) string {
+	return strings.ToLower(d.String()[:3])
+}
+
+func orDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
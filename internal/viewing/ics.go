@@ -0,0 +1,115 @@
+package viewing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Invite describes the calendar event for a booking.
+type Invite struct {
+	Booking       Booking
+	OrganizerName string
+	OrganizerMail string
+	ManageURL     string // reschedule/cancel page
+	PropertyURL   string
+}
+
+// ICS renders an RFC 5545 calendar with one event. Cancelled bookings produce a
+// METHOD:CANCEL update so calendar apps remove the event.
+func (in Invite) ICS() []byte {
+	b := in.Booking
+	method, status := "REQUEST", "CONFIRMED"
+	if !b.Active() {
+		method, status = "CANCEL", "CANCELLED"
+	}
+
+	desc := []string{"Property viewing: " + b.PropertyTitle}
+	if b.AgentName != "" {
+		desc = append(desc, "With: "+b.AgentName)
+	}
+	if in.PropertyURL != "" {
+		desc = append(desc, "Listing: "+in.PropertyURL)
+	}
+	if in.ManageURL != "" && b.Active() {
+		desc = append(desc, "Reschedule or cancel: "+in.ManageURL)
+	}
+
+	var w icsWriter
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//DhakaHome//Viewings//EN")
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:" + method)
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + b.ID + "@dhakahome")
+	w.line(fmt.Sprintf("SEQUENCE:%d", b.Sequence))
+	w.line("DTSTAMP:" + icsTime(b.UpdatedAt))
+	w.line("DTSTART:" + icsTime(b.Start))
+	w.line("DTEND:" + icsTime(b.End))
+	w.line("STATUS:" + status)
+	w.line("SUMMARY:" + icsText("Viewing: "+b.PropertyTitle))
+	if b.Address != "" {
+		w.line("LOCATION:" + icsText(b.Address))
+	}
+	w.line("DESCRIPTION:" + icsText(strings.Join(desc, "\n")))
+	if in.PropertyURL != "" {
+		w.line("URL:" + in.PropertyURL)
+	}
+	if in.OrganizerMail != "" {
+		w.line(fmt.Sprintf("ORGANIZER;CN=%s:mailto:%s", icsParam(in.OrganizerName), in.OrganizerMail))
+	}
+	if b.Email != "" {
+		w.line(fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:%s", icsParam(b.Name), b.Email))
+	}
+	if b.Active() {
+		w.line("BEGIN:VALARM")
+		w.line("ACTION:DISPLAY")
+		w.line("DESCRIPTION:" + icsText("Property viewing in 2 hours"))
+		w.line("TRIGGER:-PT2H")
+		w.line("END:VALARM")
+	}
+	w.line("END:VEVENT")
+	w.line("END:VCALENDAR")
+	return []byte(w.String())
+}
+
+// ContentType is the MIME type for the invite, including the iTIP method.
+func (in Invite) ContentType() string {
+	if !in.Booking.Active() {
+		return "text/calendar; charset=UTF-8; method=CANCEL"
+	}
+	return "text/calendar; charset=UTF-8; method=REQUEST"
+}
+
+type icsWriter struct{ strings.Builder }
+
+// line writes one content line folded at 75 octets, as RFC 5545 requires.
+func (w *icsWriter) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func icsParam(s string) string {
+	s = strings.NewReplacer(`"`, "", "\r", " ", "\n", " ").Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,226 @@
+package viewing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/BohoBytes/dhakahome-web/internal/config"
+	"github.com/BohoBytes/dhakahome-web/internal/jsonstore"
+)
+
+var (
+	ErrNotFound  = errors.New("viewing: booking not found")
+	ErrSlotTaken = errors.New("viewing: slot already booked")
+	ErrCancelled = errors.New("viewing: booking was cancelled")
+)
+
+const (
+	StatusConfirmed = "confirmed"
+	StatusCancelled = "cancelled"
+)
+
+// Booking is one visitor's viewing appointment.
+type Booking struct {
+	ID            string    `json:"id"`
+	AssetID       string    `json:"assetId"`
+	PropertyTitle string    `json:"propertyTitle"`
+	Address       string    `json:"address,omitempty"`
+	AgentID       string    `json:"agentId"`
+	AgentName     string    `json:"agentName,omitempty"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Phone         string    `json:"phone"`
+	Notes         string    `json:"notes,omitempty"`
+	Status        string    `json:"status"`
+	LeadID        string    `json:"leadId,omitempty"`
+	// Sequence increases with every change so calendar apps replace the old invite.
+	Sequence  int       `json:"sequence"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Active reports whether the booking still holds its slot.
+func (b Booking) Active() bool { return b.Status == StatusConfirmed }
+
+// Store persists bookings and guarantees an agent is never double-booked.
+type Store interface {
+	// Create stores b as confirmed, or returns ErrSlotTaken.
+	Create(b Booking) (Booking, error)
+	Get(id string) (Booking, error)
+	// Reschedule moves a confirmed booking to another slot.
+	Reschedule(id string, slot Slot) (Booking, error)
+	Cancel(id string) (Booking, error)
+	// Taken returns active bookings overlapping [from, to).
+	Taken(from, to time.Time) []Booking
+	// SetLeadID records the Nestlo lead reference created for the booking.
+	SetLeadID(id, leadID string) error
+}
+
+// NewStoreFromEnv returns a file store at VIEWING_BOOKINGS_PATH (default data/viewings.json).
+// Set VIEWING_BOOKINGS_PATH=memory to keep bookings in memory only.
+func NewStoreFromEnv() Store {
+	return NewFileStore(config.Get().Stores.ViewingBookings)
+}
+
+// FileStore keeps bookings in memory and snapshots them to a JSON file on each write.
+type FileStore struct {
+	mu       sync.Mutex
+	file     *jsonstore.File
+	bookings map[string]Booking
+}
+
+func NewFileStore(path string) *FileStore {
+	s := &FileStore{bookings: make(map[string]Booking)}
+	s.file = jsonstore.Open("viewing", path, &s.bookings)
+	return s
+}
+
+func (s *FileStore) Create(b Booking) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clashes("", b.AgentID, b.Start, b.End) {
+		return Booking{}, ErrSlotTaken
+	}
+	now := time.Now().UTC()
+	b.ID = newBookingID()
+	b.Status = StatusConfirmed
+	b.CreatedAt, b.UpdatedAt = now, now
+	s.bookings[b.ID] = b
+	if err := s.save(); err != nil {
+		delete(s.bookings, b.ID)
+		return Booking{}, err
+	}
+	return b, nil
+}
+
+func (s *FileStore) Get(id string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[id]
+	if !ok {
+		return Booking{}, ErrNotFound
+	}
+	return b, nil
+}
+
+func (s *FileStore) Reschedule(id string, slot Slot) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[id]
+	if !ok {
+		return Booking{}, ErrNotFound
+	}
+	if !b.Active() {
+		return Booking{}, ErrCancelled
+	}
+	if s.clashes(id, slot.AgentID, slot.Start, slot.End) {
+		return Booking{}, ErrSlotTaken
+	}
+	prev := b
+	b.Start, b.End = slot.Start, slot.End
+	b.AgentID, b.AgentName = slot.AgentID, slot.AgentName
+	b.Sequence++
+	b.UpdatedAt = time.Now().UTC()
+	s.bookings[id] = b
+	if err := s.save(); err != nil {
+		s.bookings[id] = prev
+		return Booking{}, err
+	}
+	return b, nil
+}
+
+func (s *FileStore) Cancel(id string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[id]
+	if !ok {
+		return Booking{}, ErrNotFound
+	}
+	if !b.Active() {
+		return Booking{}, ErrCancelled
+	}
+	prev := b
+	b.Status = StatusCancelled
+	b.Sequence++
+	b.UpdatedAt = time.Now().UTC()
+	s.bookings[id] = b
+	if err := s.save(); err != nil {
+		s.bookings[id] = prev
+		return Booking{}, err
+	}
+	return b, nil
+}
+
+func (s *FileStore) Taken(from, to time.Time) []Booking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Booking
+	for _, b := range s.bookings {
+		if b.Active() && b.Start.Before(to) && b.End.After(from) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func (s *FileStore) SetLeadID(id, leadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bookings[id]
+	if !ok {
+		return ErrNotFound
+	}
+	b.LeadID = leadID
+	s.bookings[id] = b
+	return s.save()
+}
+
+// clashes reports whether agentID already has an active booking overlapping [start, end),
+// ignoring the booking being moved. Caller holds s.mu.
+func (s *FileStore) clashes(ignoreID, agentID string, start, end time.Time) bool {
+	for id, b := range s.bookings {
+		if id == ignoreID || !b.Active() || b.AgentID != agentID {
+			continue
+		}
+		if b.Start.Before(end) && b.End.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// save snapshots the bookings. Caller holds s.mu.
+func (s *FileStore) save() error {
+	return s.file.Save(s.bookings)
+}
+
+// FreeSlots drops slots that overlap an active booking with the same agent.
+func FreeSlots(slots []Slot, taken []Booking) []Slot {
+	out := slots[:0:0]
+	for _, sl := range slots {
+		free := true
+		for _, b := range taken {
+			if b.AgentID == sl.AgentID && b.Start.Before(sl.End) && b.End.After(sl.Start) {
+				free = false
+				break
+			}
+		}
+		if free {
+			out = append(out, sl)
+		}
+	}
+	return out
+}
+
+func newBookingID() string {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return "vw_" + hex.EncodeToString(b)
+}